@@ -0,0 +1,292 @@
+package px
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+var aliasRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Aggregate is an aggregate column of GroupBy, created by CountAs, SumAs etc.
+type Aggregate struct {
+	Func   string
+	Column string
+	As     string
+}
+
+func CountAs(as string) Aggregate       { return Aggregate{Func: "count", Column: "*", As: as} }
+func SumAs(column, as string) Aggregate { return Aggregate{Func: "sum", Column: column, As: as} }
+func AvgAs(column, as string) Aggregate { return Aggregate{Func: "avg", Column: column, As: as} }
+func MinAs(column, as string) Aggregate { return Aggregate{Func: "min", Column: column, As: as} }
+func MaxAs(column, as string) Aggregate { return Aggregate{Func: "max", Column: column, As: as} }
+func CountDistinctAs(column, as string) Aggregate {
+	return Aggregate{Func: "count distinct", Column: column, As: as}
+}
+
+// aggregateColumn validates column and returns it qualified with the table name
+func (b *BaseModel[T]) aggregateColumn(column string) (string, error) {
+	if b.columnIndex(column) == -1 {
+		return "", errors.New("unknown column '" + column + "' for table " + b.TableName)
+	}
+	return b.TableName + "." + column, nil
+}
+
+// aggregateSQL returns 'fn(table.column) as alias'
+func (b *BaseModel[T]) aggregateSQL(a Aggregate) (string, error) {
+	if !aliasRegexp.MatchString(a.As) {
+		return "", errors.New("invalid aggregate alias '" + a.As + "'")
+	}
+	column := a.Column
+	if column != "*" {
+		var e error
+		column, e = b.aggregateColumn(column)
+		if e != nil {
+			return "", e
+		}
+	}
+	switch a.Func {
+	case "count":
+		return "count(" + column + ") as " + a.As, nil
+	case "count distinct":
+		return "count(distinct " + column + ") as " + a.As, nil
+	case "sum", "avg", "min", "max":
+		if column == "*" {
+			return "", errors.New(a.Func + "(*) is not supported")
+		}
+		return a.Func + "(" + column + ") as " + a.As, nil
+	}
+	return "", errors.New("unsupported aggregate function:" + a.Func)
+}
+
+// aggregate scans the single value of 'select expr from table where'
+func (b *BaseModel[T]) aggregate(expr, where string, args []any, dst any) error {
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return e
+	}
+	query := `select ` + expr + ` from ` + b.TableName + where
+	e = b.Executor().QueryRow(context.Background(), query, args...).Scan(dst)
+	if e != nil {
+		return b.toError(e, query)
+	}
+	return nil
+}
+
+// Sum sums column over the documents that match 'where' condition, 0 if none does.
+// Sums above 2^53 lose precision, see SumInt for integer columns
+func (b *BaseModel[T]) Sum(column, where string, args ...any) (float64, error) {
+	column, e := b.aggregateColumn(column)
+	if e != nil {
+		return 0, e
+	}
+	var v float64
+	e = b.aggregate(`coalesce(sum(`+column+`),0)::double precision`, where, args, &v)
+	return v, e
+}
+
+// SumInt sums an integer column over the documents that match 'where' condition exactly, 0 if none does.
+// It fails if the sum overflows int64
+func (b *BaseModel[T]) SumInt(column, where string, args ...any) (int64, error) {
+	column, e := b.aggregateColumn(column)
+	if e != nil {
+		return 0, e
+	}
+	var v int64
+	e = b.aggregate(`coalesce(sum(`+column+`),0)::bigint`, where, args, &v)
+	return v, e
+}
+
+// Avg averages column over the documents that match 'where' condition, ErrNotFound if none does
+func (b *BaseModel[T]) Avg(column, where string, args ...any) (float64, error) {
+	column, e := b.aggregateColumn(column)
+	if e != nil {
+		return 0, e
+	}
+	var v sql.NullFloat64
+	e = b.aggregate(`avg(`+column+`)::double precision`, where, args, &v)
+	if e != nil {
+		return 0, e
+	}
+	if !v.Valid {
+		return 0, ErrNotFound
+	}
+	return v.Float64, nil
+}
+
+// Min returns the minimum of column over the documents of model b that match 'where' condition, ErrNotFound if none does
+func Min[V any, T any](b *BaseModel[T], column, where string, args ...any) (V, error) {
+	return minMax[V](b, "min", column, where, args)
+}
+
+// Max returns the maximum of column over the documents of model b that match 'where' condition, ErrNotFound if none does
+func Max[V any, T any](b *BaseModel[T], column, where string, args ...any) (V, error) {
+	return minMax[V](b, "max", column, where, args)
+}
+
+func minMax[V any, T any](b *BaseModel[T], fn, column, where string, args []any) (V, error) {
+	var zero V
+	column, e := b.aggregateColumn(column)
+	if e != nil {
+		return zero, e
+	}
+	var v *V
+	e = b.aggregate(fn+`(`+column+`)`, where, args, &v)
+	if e != nil {
+		return zero, e
+	}
+	if v == nil {
+		return zero, ErrNotFound
+	}
+	return *v, nil
+}
+
+// Distinct returns the distinct values of column over the documents of model b that match 'where' condition, in ascending order
+func Distinct[V any, T any](b *BaseModel[T], column, where string, args ...any) ([]V, error) {
+	column, e := b.aggregateColumn(column)
+	if e != nil {
+		return nil, e
+	}
+	where, args, e = b.bindWhere(where, args)
+	if e != nil {
+		return nil, e
+	}
+
+	//query
+	query := `select distinct ` + column + ` from ` + b.TableName + where + ` order by ` + column
+	rows, e := b.Executor().Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	vs := []V{}
+	for rows.Next() {
+		var v V
+		e = rows.Scan(&v)
+		if e != nil {
+			break
+		}
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, b.toError(e, query)
+	}
+	return vs, nil
+}
+
+// GroupBy groups the documents of model b that match 'where' condition by the key columns, scanning each group into R.
+// R's fields are matched by snake_case name against the key columns and the aggregates' As names:
+//
+//	type Stat struct {
+//		Country string
+//		Users   int64
+//	}
+//	stats, e := px.GroupBy[Stat](users, []string{"country"}, []px.Aggregate{px.CountAs("users")}, "")
+func GroupBy[R any, T any](b *BaseModel[T], keys []string, aggregates []Aggregate, where string, args ...any) ([]R, error) {
+	var r R
+	t := reflect.TypeOf(r)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("group by result type must be struct type")
+	}
+	rFields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			rFields[strcase.ToSnake(t.Field(i).Name)] = i
+		}
+	}
+
+	//select
+	selection := []string{}
+	groups := []string{}
+	fields := []int{}
+	for _, key := range keys {
+		column, e := b.aggregateColumn(key)
+		if e != nil {
+			return nil, e
+		}
+		i, ok := rFields[key]
+		if !ok {
+			return nil, errors.New(t.String() + " has no field for key column '" + key + "'")
+		}
+		selection = append(selection, column)
+		groups = append(groups, column)
+		fields = append(fields, i)
+	}
+	for _, a := range aggregates {
+		s, e := b.aggregateSQL(a)
+		if e != nil {
+			return nil, e
+		}
+		i, ok := rFields[a.As]
+		if !ok {
+			return nil, errors.New(t.String() + " has no field for aggregate '" + a.As + "'")
+		}
+		selection = append(selection, s)
+		fields = append(fields, i)
+	}
+	if len(selection) == 0 {
+		return nil, errors.New("no key column or aggregate")
+	}
+
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return nil, e
+	}
+	where, e = groupBy(where, groups)
+	if e != nil {
+		return nil, e
+	}
+	query := `select ` + strings.Join(selection, ",") + ` from ` + b.TableName + where
+
+	//query
+	rows, e := b.Executor().Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	vs := []R{}
+	for rows.Next() {
+		var v R
+		e = rows.Scan(projectionArgs(&v, fields)...)
+		if e != nil {
+			break
+		}
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, b.toError(e, query)
+	}
+	return vs, nil
+}
+
+// groupBy inserts 'group by groups' into where (as returned by bindWhere) before its order/limit tail,
+// ordering by groups unless the tail has its own order by
+func groupBy(where string, groups []string) (string, error) {
+	if len(groups) == 0 {
+		return where, nil
+	}
+	tail := ""
+	if i := trailingClauseIndex(where); i > -1 {
+		where, tail = where[:i], where[i:]
+	}
+	lowerTail := strings.ToLower(strings.TrimSpace(tail))
+	if strings.HasPrefix(lowerTail, "group by") {
+		return "", errors.New("where can't have a group by clause, the key columns are grouped by")
+	}
+	where += ` group by ` + strings.Join(groups, ",")
+	if !strings.HasPrefix(lowerTail, "order by") {
+		where += ` order by ` + strings.Join(groups, ",")
+	}
+	return where + tail, nil
+}
@@ -0,0 +1,31 @@
+package px
+
+import "testing"
+
+func TestGroupBy(t *testing.T) {
+	tests := []struct {
+		where   string
+		groups  []string
+		want    string
+		wantErr bool
+	}{
+		{"", []string{"country"}, " group by country order by country", false},
+		{" where age>$1", []string{"country", "city"}, " where age>$1 group by country,city order by country,city", false},
+		{" where age>$1 limit 10", []string{"country"}, " where age>$1 group by country order by country limit 10", false},
+		{" where age>$1 order by country desc limit 10", []string{"country"}, " where age>$1 group by country order by country desc limit 10", false},
+		{" order by country desc", []string{"country"}, " group by country order by country desc", false},
+		{" where note='x order by y'", []string{"country"}, " where note='x order by y' group by country order by country", false},
+		{" where age>$1 limit 10", nil, " where age>$1 limit 10", false},
+		{" where age>$1 group by country", []string{"country"}, "", true},
+	}
+	for _, tt := range tests {
+		got, e := groupBy(tt.where, tt.groups)
+		if (e != nil) != tt.wantErr {
+			t.Errorf("groupBy(%q) error %v", tt.where, e)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("groupBy(%q) = %q, want %q", tt.where, got, tt.want)
+		}
+	}
+}
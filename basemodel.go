@@ -2,13 +2,13 @@ package px
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -23,8 +23,24 @@ type BaseModel[T any] struct {
 	Schema    string
 	TableName string
 
-	dbTags  []string
-	pgTypes []string
+	dbTags      []string
+	pgTypes     []string
+	comments    []string // comment tag of each column, "" if none
+	fields      []int    // struct field index of each column, fields tagged px:"-" aren't columns
+	primaryKeys []int    // column indexes of the primary key columns, in field order
+	softDelete  int      // column index of the px:"softdelete" column, -1 if none
+	created     int      // column index of the px:"created" column, -1 if none
+	updated     int      // column index of the px:"updated" column, -1 if none
+	version     int      // column index of the px:"version" column, -1 if none
+	unscoped    bool
+	tx          Executor
+	relations   map[string]relation[T]
+	preloads    []string
+	plan        *plan // statements built once by NewBaseModel, shared by copies
+
+	primaryKeyModel *indexModel // from the 'group=pkey' index tags, nil for the id primary key
+	indexes         []indexModel
+	renamedFrom     map[string][]string // column -> its former names, from renamed_from tags
 }
 
 const (
@@ -34,6 +50,13 @@ const (
 var (
 	AutoSyncTableSchema  = false
 	AutoDropRemoteColumn = false
+	// ConcurrentIndexes makes schema sync create and drop the indexes of existing tables concurrently, without locking
+	// writes. A failed build leaves an invalid index, which the next sync drops and builds again
+	ConcurrentIndexes = false
+	// IndexProgressInterval is how often ApplySchema logs the progress of an index build, never if 0
+	IndexProgressInterval = 10 * time.Second
+	// NowFunc provides the time for px:"created" and px:"updated" fields, the database server's now() is used if nil
+	NowFunc func() time.Time
 )
 
 func MustNewBaseModel[T any](dsn string) *BaseModel[T] {
@@ -55,11 +78,16 @@ func NewBaseModelWithCreated[T any](dsn string) (*BaseModel[T], bool, error) {
 	t := reflect.TypeOf(data)
 
 	model := &BaseModel[T]{
-		Dsn:       dsn,
-		Type:      t,
-		Database:  strToolkit.SubAfterLast(dsn, "/", ""),
-		Schema:    "public",
-		TableName: ToTableName(t.Name()),
+		Dsn:        dsn,
+		Type:       t,
+		Database:   strToolkit.SubAfterLast(dsn, "/", ""),
+		Schema:     "public",
+		TableName:  ToTableName(t.Name()),
+		softDelete: -1,
+		created:    -1,
+		updated:    -1,
+		version:    -1,
+		relations:  make(map[string]relation[T]),
 	}
 
 	//validate
@@ -84,7 +112,11 @@ func NewBaseModelWithCreated[T any](dsn string) (*BaseModel[T], bool, error) {
 
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if i == 0 {
+		if field.Tag.Get("px") == "-" {
+			continue
+		}
+		column := len(model.dbTags)
+		if column == 0 {
 			switch field.Type.Kind() {
 			case reflect.Uint,
 				reflect.Uint64,
@@ -98,15 +130,58 @@ func NewBaseModelWithCreated[T any](dsn string) (*BaseModel[T], bool, error) {
 
 		//dbTag
 		dbTag := strcase.ToSnake(field.Name)
-		if i == 0 && dbTag != "id" {
+		if column == 0 && dbTag != "id" {
 			return nil, false, errors.New("The first field's name must be Id or ID")
 		}
 
+		//px
+		if pxTag, ok := field.Tag.Lookup("px"); ok {
+			for _, option := range strings.Split(pxTag, ",") {
+				switch strings.TrimSpace(option) {
+				case "softdelete":
+					if field.Type.String() != "sql.NullTime" && field.Type.String() != "*time.Time" {
+						return nil, false, errors.New("The softdelete field " + field.Name + "'s type must be one of sql.NullTime,*time.Time")
+					}
+					model.softDelete = column
+				case "created", "updated":
+					if !isTimeType(field.Type) {
+						return nil, false, errors.New("The " + option + " field " + field.Name + "'s type must be one of time.Time,sql.NullTime,*time.Time")
+					}
+					if option == "created" {
+						model.created = column
+					} else {
+						model.updated = column
+					}
+				case "version":
+					switch field.Type.Kind() {
+					case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint, reflect.Uint64, reflect.Uint32:
+					default:
+						return nil, false, errors.New("The version field " + field.Name + "'s type must be an integer")
+					}
+					model.version = column
+				default:
+					return nil, false, errors.New("Invalid px tag option:" + option + " for field " + field.Name)
+				}
+			}
+		}
+
 		//index
 		if index, ok := field.Tag.Lookup("index"); ok {
 			indexes[dbTag] = index
 		}
 
+		//renamed_from, the former column names, latest first
+		if renamedFrom, ok := field.Tag.Lookup("renamed_from"); ok {
+			if model.renamedFrom == nil {
+				model.renamedFrom = make(map[string][]string)
+			}
+			for _, name := range strings.Split(renamedFrom, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					model.renamedFrom[dbTag] = append(model.renamedFrom[dbTag], name)
+				}
+			}
+		}
+
 		//limit
 		limit := 0
 		if limitStr, ok := field.Tag.Lookup("limit"); ok {
@@ -134,178 +209,41 @@ func NewBaseModelWithCreated[T any](dsn string) (*BaseModel[T], bool, error) {
 
 		model.dbTags = append(model.dbTags, dbTag)
 		model.pgTypes = append(model.pgTypes, pgType)
+		model.comments = append(model.comments, field.Tag.Get("comment"))
+		model.fields = append(model.fields, i)
 	}
 	primaryKeyModel, localIndexList, e := toIndexModels(indexes)
 	if e != nil {
 		log.Println(e)
 		return nil, false, e
 	}
+	e = model.setPrimaryKeys(primaryKeyModel)
+	if e != nil {
+		log.Println(e)
+		return nil, false, e
+	}
+	model.plan = model.buildPlan()
+
+	model.primaryKeyModel = primaryKeyModel
+	model.indexes = localIndexList
 
 	if AutoSyncTableSchema {
-		//desc
-		remoteColumnList, e := DescTable(model.Pool, model.Database, model.Schema, model.TableName)
+		plan, e := model.PlanSchema()
 		if e != nil {
 			log.Println(e)
 			return nil, false, e
 		}
-
-		//create table
-		if len(remoteColumnList) == 0 {
-			e = model.createTable(primaryKeyModel)
-			if e != nil {
-				log.Println(e)
-				return nil, false, e
-			}
-			//create index
-			e = model.createIndexFromField(localIndexList)
-			if e != nil {
-				log.Println(e)
-				return nil, false, e
-			}
-			return model, true, nil
-		}
-
-		// columns check
-		remoteColumns := make(map[string]Column)
-		for _, c := range remoteColumnList {
-			remoteColumns[c.ColumnName] = c
-		}
-
-		// local columns to be created
-		localColumns := make(map[string]string)
-		for i, db := range model.dbTags {
-			localColumns[db] = model.pgTypes[i]
-
-			remote, ok := remoteColumns[db]
-			if !ok {
-				//auto-create field on remote database
-				log.Println("Remote column '" + db + "' to be created")
-				e = model.addColumn(db, model.pgTypes[i])
-				if e != nil {
-					log.Println(e)
-					return nil, false, e
-				}
-				continue
-			}
-
-			//type check
-			dbType := toPgPrimitiveType(model.pgTypes[i])
-			remoteType := strToolkit.SubBefore(remote.DataType, " ", remote.DataType)
-			if strings.HasSuffix(dbType, "[]") {
-				dbType = "ARRAY"
-			}
-			if dbType != remoteType {
-				return nil, false, errors.New("Found local field " + db + "'s type '" + dbType + "' doesn't match remote column type:" + remoteType)
-			}
-			if strings.Contains(model.pgTypes[i], "not null") != (remote.IsNullable == "NO") {
-				return nil, false, errors.New("Found local field " + db + "'s nullability '" + model.pgTypes[i] + "' doesn't match remote column nullability :" + remote.IsNullable)
-			}
-		}
-
-		//remote columns to be dropped
-		for _, remote := range remoteColumnList {
-			_, ok := localColumns[remote.ColumnName]
-			if !ok {
-				if AutoDropRemoteColumn {
-					//auto-drop remote column
-					log.Println("Remote column '" + remote.ColumnName + "' to be dropped")
-					e = model.dropColumn(remote.ColumnName)
-					if e != nil {
-						log.Println(e)
-						return nil, false, e
-					}
-					continue
-				}
-				return nil, false, errors.New("Remote column '" + remote.ColumnName + "' to be dropped")
-			}
-		}
-
-		// index check
-		remoteIndexList, e := model.GetIndexes()
+		e = model.ApplySchema(plan)
 		if e != nil {
 			log.Println(e)
 			return nil, false, e
 		}
-		remoteIndexes := make(map[string]IndexSchema)
-		for _, remote := range remoteIndexList {
-			remoteIndexes[remote.IndexName] = remote
-		}
-
-		// indexes to be created
-		localIndexes := make(map[string]indexModel)
-		for _, local := range localIndexList {
-			localIndexes[local.ToIndexName(model.TableName)] = local
-			remote, ok := remoteIndexes[local.ToIndexName(model.TableName)]
-			if !ok {
-				//auto-create index on remote database
-				log.Println("Remote index '" + local.ToIndexName(model.TableName) + "' to be created")
-				e = model.createIndex(local)
-				if e != nil {
-					log.Println(e)
-					return nil, false, e
-				}
-				continue
-			}
-
-			//unique check
-			if local.unique != strings.Contains(remote.IndexDef, "UNIQUE") {
-				return nil, false, errors.New("Index '" + local.ToIndexName(model.TableName) + "' unique option is inconsistant with remote database: " + strconv.FormatBool(local.unique) + " vs " + strconv.FormatBool(strings.Contains(remote.IndexDef, "UNIQUE")))
-			}
-		}
-
-		//indexes to be dropped
-		for _, remote := range remoteIndexList {
-			log.Println(remote.IndexName)
-			if strings.Contains(remote.IndexName, "_pkey") {
-				continue
-			}
-
-			_, ok := localIndexes[remote.IndexName]
-			if !ok {
-				if !strToolkit.SliceContains(model.dbTags, convertIndexToFieldName(model.TableName, remote.IndexName)) {
-					continue
-				}
-				log.Println("Remote index '" + remote.IndexName + "' to be dropped")
-				e = model.dropIndex(remote.IndexName)
-				if e != nil {
-					log.Println(e)
-					return nil, false, e
-				}
-				continue
-			}
-		}
+		created = plan.Created()
 	}
 
 	return model, created, nil
 }
 
-func (b *BaseModel[T]) createTable(primaryKeyModel *indexModel) error {
-	query := b.GetCreateTableSQL(primaryKeyModel)
-	_, e := b.Pool.Exec(context.Background(), query)
-	if e != nil {
-		return fmt.Errorf("%w: %s", e, query)
-	}
-	return nil
-}
-
-func (b *BaseModel[T]) addColumn(name, typ string) error {
-	_, e := b.Pool.Exec(context.Background(), `alter table `+b.Schema+`.`+b.TableName+` add column `+name+` `+typ)
-	if e != nil {
-		log.Println(e)
-		return e
-	}
-	return e
-}
-
-func (b *BaseModel[T]) dropColumn(name string) error {
-	_, e := b.Pool.Exec(context.Background(), `alter table `+b.Schema+`.`+b.TableName+` drop column `+name)
-	if e != nil {
-		log.Println(e)
-		return e
-	}
-	return nil
-}
-
 func (b *BaseModel[T]) GetCreateTableSQL(primaryKeyModel *indexModel) string {
 	builder := new(strings.Builder)
 	builder.WriteString(`create table ` + b.Schema + `.` + b.TableName + ` (`)
@@ -323,12 +261,11 @@ func (b *BaseModel[T]) GetCreateTableSQL(primaryKeyModel *indexModel) string {
 		builder.WriteString(", primary key (" + primaryKeyModel.JoinKeys(",") + ")")
 	}
 	builder.WriteString(`)`)
-	log.Println(builder.String())
 	return builder.String()
 }
 
-// GetInsertSQL returns insert SQL without returning id
-func (b *BaseModel[T]) GetInsertSQL() ([]int, string) {
+// buildInsertSQL builds GetInsertSQL's statement
+func (b *BaseModel[T]) buildInsertSQL() ([]int, string) {
 	builder := new(strings.Builder)
 	builder.WriteString(`insert into ` + b.Schema + `.` + b.TableName + ` (`)
 
@@ -346,7 +283,11 @@ func (b *BaseModel[T]) GetInsertSQL() ([]int, string) {
 		argsIndex = append(argsIndex, i)
 
 		builder.WriteString(dbTag)
-		values.WriteString("$" + strconv.Itoa(len(argsIndex)))
+		if b.isAutoTime(i) {
+			values.WriteString("coalesce($" + strconv.Itoa(len(argsIndex)) + ",now())")
+		} else {
+			values.WriteString("$" + strconv.Itoa(len(argsIndex)))
+		}
 
 		if i < len(b.dbTags)-1 {
 			builder.WriteString(",")
@@ -363,14 +304,8 @@ func (b *BaseModel[T]) GetInsertSQL() ([]int, string) {
 	return argsIndex, builder.String()
 }
 
-// GetInsertReturningSQL returns insert SQL with returning id
-func (b *BaseModel[T]) GetInsertReturningSQL() ([]int, string) {
-	argsIndex, query := b.GetInsertSQL()
-	return argsIndex, query + " returning " + b.dbTags[0]
-}
-
-// GetSelectSQL returns fieldIndexes, and select SQL
-func (b *BaseModel[T]) GetSelectSQL() ([]int, string) {
+// buildSelectSQL builds GetSelectSQL's statement
+func (b *BaseModel[T]) buildSelectSQL() ([]int, string) {
 	builder := new(strings.Builder)
 	builder.WriteString(`select `)
 	fieldIndexes := []int{}
@@ -385,8 +320,8 @@ func (b *BaseModel[T]) GetSelectSQL() ([]int, string) {
 	return fieldIndexes, builder.String()
 }
 
-// id,name,create_at
-func (b *BaseModel[T]) GetSelectFields() ([]int, string) {
+// buildSelectFields builds GetSelectFields's list: id,name,create_at
+func (b *BaseModel[T]) buildSelectFields() ([]int, string) {
 	builder := new(strings.Builder)
 	fieldIndexes := []int{}
 	for i, dbTag := range b.dbTags {
@@ -399,210 +334,179 @@ func (b *BaseModel[T]) GetSelectFields() ([]int, string) {
 	return fieldIndexes, builder.String()
 }
 
-// Insert inserts v (*struct or struct type)
+// Insert inserts v (*struct or struct type), returning the id, or []any of the primary key values in field order for a composite primary key
 func (b *BaseModel[T]) Insert(v T) (any, error) {
 	//validate
-	value := reflect.ValueOf(v)
+	value := reflect.ValueOf(&v).Elem()
 	t := value.Type()
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-		value = value.Elem()
-	}
 	if t.String() != b.Type.String() {
 		return nil, errors.New("Wrong insert type:" + t.String() + " for table " + b.TableName)
 	}
 
+	//hook
+	e := b.beforeInsert(&v)
+	if e != nil {
+		return nil, e
+	}
+
 	//args
 	argsIndex, query := b.GetInsertReturningSQL()
+	values := b.columnValues(value)
 	args := []any{}
 	for _, i := range argsIndex {
-		field := value.Field(i)
-		args = append(args, field.Interface())
+		if b.isAutoTime(i) && b.field(value, i).IsZero() {
+			args = append(args, autoTime())
+			continue
+		}
+		args = append(args, b.columnValue(values, value, i))
 	}
 
-	//exec
-	id := reflect.New(b.Type.Field(0).Type)
-	e := b.Pool.QueryRow(context.Background(), query, args...).Scan(id.Interface())
+	keys, e := b.queryKeys(query, args, value)
 	if e != nil {
 		return nil, e
 	}
-
-	return id.Elem().Interface(), nil
+	e = b.afterInsert(&v)
+	if e != nil {
+		return nil, e
+	}
+	return keys, nil
 }
 
-// Find finds a document (*struct type) by id
-func (b *BaseModel[T]) Find(id any) (*T, error) {
-	//scan
-	v := reflect.New(b.Type)
-	fieldIndexes, query := b.GetSelectSQL()
-	fieldArgs := []any{}
-	for _, i := range fieldIndexes {
-		fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
+// queryKeys runs an insert ... returning primary keys query, scanning the keys into value. See Insert for the returned value
+func (b *BaseModel[T]) queryKeys(query string, args []any, value reflect.Value) (any, error) {
+	keys := []any{}
+	for _, i := range b.primaryKeys {
+		keys = append(keys, b.field(value, i).Addr().Interface())
 	}
+	e := b.Executor().QueryRow(context.Background(), query, args...).Scan(keys...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	if len(keys) == 1 {
+		return b.field(value, b.primaryKeys[0]).Interface(), nil
+	}
+	for n, i := range b.primaryKeys {
+		keys[n] = b.field(value, i).Interface()
+	}
+	return keys, nil
+}
 
-	query = query + ` where ` + b.dbTags[0] + `=$1`
-	e := b.Pool.QueryRow(context.Background(), query, id).Scan(fieldArgs...)
+// bindWhere applies toWhere, named parameters and the soft delete scope to a user supplied where condition
+func (b *BaseModel[T]) bindWhere(where string, args []any) (string, []any, error) {
+	texts, args, e := bindNamed(args, toWhere(where))
 	if e != nil {
-		if strings.Contains(e.Error(), "no rows") {
-			return nil, sql.ErrNoRows
-		}
-		return nil, fmt.Errorf("%w:%s", e, query)
+		return "", nil, e
 	}
-	return v.Interface().(*T), nil
+	return b.scope(texts[0]), args, nil
+}
+
+// Find finds a document (*struct type) by id
+func (b *BaseModel[T]) Find(id any) (*T, error) {
+	_, query := b.GetSelectSQL()
+	query = query + b.scope(` where `+b.dbTags[0]+`=$1`)
+	return b.queryOne(query, []any{id})
 }
 
 // FindWhere finds a document (*struct type) that matches 'where' condition
 func (b *BaseModel[T]) FindWhere(where string, args ...any) (*T, error) {
 	//where
-	where = toWhere(where)
-
-	//scan
-	v := reflect.New(b.Type)
-	fieldIndexes, query := b.GetSelectSQL()
-	query = query + where
-	fieldArgs := []any{}
-	for _, i := range fieldIndexes {
-		fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
-	}
-	e := b.Pool.QueryRow(context.Background(), query, args...).Scan(fieldArgs...)
+	where, args, e := b.bindWhere(where, args)
 	if e != nil {
-		if strings.Contains(e.Error(), "no rows") {
-			return nil, sql.ErrNoRows
-		}
-		return nil, fmt.Errorf("%w:%s", e, query)
+		return nil, e
 	}
-	return v.Interface().(*T), nil
+
+	_, query := b.GetSelectSQL()
+	return b.queryOne(query+where, args)
 }
 
 // QueryWhere queries documents ([]struct type) that matches 'where' condition
 func (b *BaseModel[T]) QueryWhere(where string, args ...any) ([]T, error) {
-	where = toWhere(where)
-
-	fieldIndexes, query := b.GetSelectSQL()
-
-	//query
-	query = query + where
-	rows, e := b.Pool.Query(context.Background(), query, args...)
+	where, args, e := b.bindWhere(where, args)
 	if e != nil {
-		return nil, fmt.Errorf("%w:%s", e, query)
-	}
-
-	vs := reflect.MakeSlice(reflect.SliceOf(b.Type), 0, 2)
-	for rows.Next() {
-		v := reflect.New(b.Type)
-		fieldArgs := []any{}
-		for _, i := range fieldIndexes {
-			fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
-		}
-		e = rows.Scan(fieldArgs...)
-		if e != nil {
-			break
-		}
-		vs = reflect.Append(vs, v.Elem())
-	}
-
-	// check err
-	rows.Close()
-	if e = rows.Err(); e != nil {
 		return nil, e
 	}
 
-	return vs.Interface().([]T), nil
+	_, query := b.GetSelectSQL()
+	return b.queryAll(query+where, args)
 }
 
-// QueryWhere queries documents ([]struct type) that matches 'where' condition
+// Query queries documents ([]struct type) with a raw query trail, which is not scoped by soft delete
 func (b *BaseModel[T]) Query(queryTrail string, args ...any) ([]T, error) {
-	fieldIndexes, query := b.GetSelectSQL()
-
-	//query
-	query = query + " " + queryTrail
-	rows, e := b.Pool.Query(context.Background(), query, args...)
+	texts, args, e := bindNamed(args, queryTrail)
 	if e != nil {
-		return nil, fmt.Errorf("%w:%s", e, query)
-	}
-
-	vs := reflect.MakeSlice(reflect.SliceOf(b.Type), 0, 2)
-	for rows.Next() {
-		v := reflect.New(b.Type)
-		fieldArgs := []any{}
-		for _, i := range fieldIndexes {
-			fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
-		}
-		e = rows.Scan(fieldArgs...)
-		if e != nil {
-			break
-		}
-		vs = reflect.Append(vs, v.Elem())
-	}
-
-	// check err
-	rows.Close()
-	if e = rows.Err(); e != nil {
 		return nil, e
 	}
 
-	return vs.Interface().([]T), nil
+	_, query := b.GetSelectSQL()
+	return b.queryAll(query+" "+texts[0], args)
 }
 
 func (b *BaseModel[T]) Exists(id any) (bool, error) {
 	//scan
 	num := 0
-	query := `select 1 from ` + b.TableName + ` where ` + b.dbTags[0] + `=$1 limit 1`
-	e := b.Pool.QueryRow(context.Background(), query, id).Scan(&num)
+	query := `select 1 from ` + b.TableName + b.scope(` where `+b.dbTags[0]+`=$1`) + ` limit 1`
+	e := b.Executor().QueryRow(context.Background(), query, id).Scan(&num)
 	if e != nil {
-		if strings.Contains(e.Error(), "no rows") {
-			return false, sql.ErrNoRows
-		}
-		return false, fmt.Errorf("%w:%s", e, query)
+		return false, b.toError(e, query)
 	}
 	return num > 0, nil
 }
 
 func (b *BaseModel[T]) ExistsWhere(where string, args ...any) (bool, error) {
 	//where
-	where = toWhere(where)
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return false, e
+	}
 
 	//scan
 	num := 0
 	query := `select 1 from ` + b.TableName + where + ` limit 1`
-	e := b.Pool.QueryRow(context.Background(), query, args...).Scan(&num)
+	e = b.Executor().QueryRow(context.Background(), query, args...).Scan(&num)
 	if e != nil {
-		if strings.Contains(e.Error(), "no rows") {
-			return false, sql.ErrNoRows
-		}
-		return false, fmt.Errorf("%w:%s", e, query)
+		return false, b.toError(e, query)
 	}
 	return num > 0, nil
 }
 
 func (b *BaseModel[T]) CountWhere(where string, args ...any) (int64, error) {
-	where = toWhere(where)
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return 0, e
+	}
 
 	//scan
 	var num int64
 	query := `select count(*) as count from ` + b.TableName + where
-	e := b.Pool.QueryRow(context.Background(), query, args...).Scan(&num)
+	e = b.Executor().QueryRow(context.Background(), query, args...).Scan(&num)
 	if e != nil {
-		return 0, fmt.Errorf("%w:%s", e, query)
+		return 0, b.toError(e, query)
 	}
 	return num, nil
 }
 
+// UpdateSet updates the documents that match 'where' condition, hooks are not called as no document is loaded
 func (b *BaseModel[T]) UpdateSet(where, sets string, args ...any) (int64, error) {
-	where = toWhere(where)
+	texts, args, e := bindNamed(args, sets, toWhere(where))
+	if e != nil {
+		return 0, e
+	}
+	sets, where = texts[0], texts[1]
+	sets, args = b.autoUpdateSet(sets, args)
 	query := `update ` + b.TableName + ` set ` + sets + where
-	result, e := b.Pool.Exec(context.Background(), query, args...)
+	result, e := b.Executor().Exec(context.Background(), query, args...)
 	if e != nil {
-		return 0, fmt.Errorf("%w:%s", e, query)
+		return 0, b.toError(e, query)
 	}
 	return result.RowsAffected(), nil
 }
 
 func (b *BaseModel[T]) Clear() error {
 	query := `truncate table ` + b.TableName
-	_, e := b.Pool.Exec(context.Background(), query)
+	_, e := b.Executor().Exec(context.Background(), query)
 	if e != nil {
-		return fmt.Errorf("%w:%s", e, query)
+		return b.toError(e, query)
 	}
 	return nil
 }
@@ -611,78 +515,75 @@ func (b *BaseModel[T]) Truncate() error {
 	return b.Clear()
 }
 
+// Delete deletes the document by id, or marks it deleted if the model has a px:"softdelete" field
 func (b *BaseModel[T]) Delete(id any) (int64, error) {
-	query := `delete from ` + b.TableName + ` where ` + b.dbTags[0] + `=$1`
-	result, e := b.Pool.Exec(context.Background(), query, id)
+	return b.deleteWhere(b.scope(` where `+b.dbTags[0]+`=$1`), []any{id})
+}
+
+// DeleteWhere deletes documents that match 'where' condition, or marks them deleted if the model has a px:"softdelete" field
+func (b *BaseModel[T]) DeleteWhere(where string, args ...any) (int64, error) {
+	where, args, e := b.bindWhere(where, args)
 	if e != nil {
-		return 0, fmt.Errorf("%w:%s", e, query)
+		return 0, e
 	}
-	return result.RowsAffected(), nil
+	return b.deleteWhere(where, args)
 }
 
-func (b *BaseModel[T]) DeleteWhere(where string, args ...any) (int64, error) {
-	where = toWhere(where)
+// deleteWhere runs BeforeDelete hooks and deletes the rows matching where (as returned by scope)
+func (b *BaseModel[T]) deleteWhere(where string, args []any) (int64, error) {
+	e := b.beforeDelete(where, args)
+	if e != nil {
+		return 0, e
+	}
 
-	query := `delete from ` + b.TableName + where
-	result, e := b.Pool.Exec(context.Background(), query, args...)
+	query := b.deleteSQL() + where
+	result, e := b.Executor().Exec(context.Background(), query, args...)
 	if e != nil {
-		return 0, fmt.Errorf("%w:%s", e, query)
+		return 0, b.toError(e, query)
 	}
 	return result.RowsAffected(), nil
 }
 
 func (b *BaseModel[T]) FindAndUpdateSet(where, sets string, args ...any) (*T, error) {
-	where = toWhere(where)
-	query := `update ` + b.TableName + ` set ` + sets + where
-	//scan
-	v := reflect.New(b.Type)
-	fieldIndexes, selection := b.GetSelectFields()
-	fieldArgs := []any{}
-	for _, i := range fieldIndexes {
-		fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
+	texts, args, e := bindNamed(args, sets, toWhere(where))
+	if e != nil {
+		return nil, e
 	}
+	sets, where = texts[0], texts[1]
+	sets, args = b.autoUpdateSet(sets, args)
+	query := `update ` + b.TableName + ` set ` + sets + where
+	_, selection := b.GetSelectFields()
 
-	query = query + ` returning ` + selection
-	e := b.Pool.QueryRow(context.Background(), query, args...).Scan(fieldArgs...)
+	v, e := b.queryOne(query+` returning `+selection, args)
 	if e != nil {
-		if strings.Contains(e.Error(), "no rows") {
-			return nil, sql.ErrNoRows
-		}
-		return nil, fmt.Errorf("%w:%s", e, query)
+		return nil, e
 	}
-	return v.Interface().(*T), nil
+	e = b.afterUpdate(v)
+	if e != nil {
+		return nil, e
+	}
+	return v, nil
 }
 
 func (b *BaseModel[T]) QueryAndUpdateSet(where, sets string, args ...any) ([]T, error) {
-	where = toWhere(where)
+	texts, args, e := bindNamed(args, sets, toWhere(where))
+	if e != nil {
+		return nil, e
+	}
+	sets, where = texts[0], texts[1]
+	sets, args = b.autoUpdateSet(sets, args)
 	query := `update ` + b.TableName + ` set ` + sets + where
-	//scan
-	fieldIndexes, selection := b.GetSelectFields()
+	_, selection := b.GetSelectFields()
 
-	query = query + ` returning ` + selection
-	rows, e := b.Pool.Query(context.Background(), query, args...)
+	vs, e := b.queryAll(query+` returning `+selection, args)
 	if e != nil {
-		return nil, fmt.Errorf("%w:%s", e, query)
-	}
-	vs := reflect.MakeSlice(reflect.SliceOf(b.Type), 0, 2)
-	for rows.Next() {
-		v := reflect.New(b.Type)
-		fieldArgs := []any{}
-		for _, i := range fieldIndexes {
-			fieldArgs = append(fieldArgs, v.Elem().Field(i).Addr().Interface())
-		}
-		e = rows.Scan(fieldArgs...)
+		return nil, e
+	}
+	for i := range vs {
+		e = b.afterUpdate(&vs[i])
 		if e != nil {
-			break
+			return nil, e
 		}
-		vs = reflect.Append(vs, v.Elem())
 	}
-
-	// check err
-	rows.Close()
-	if e = rows.Err(); e != nil {
-		return nil, e
-	}
-
-	return vs.Interface().([]T), nil
+	return vs, nil
 }
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/stevenzack/px"
+)
+
+type genModel struct {
+	name    string
+	columns []genColumn
+}
+
+type genColumn struct {
+	field  string
+	dbTag  string
+	goType string
+}
+
+// gen writes reflection-free accessors of the model structs of a package, see px.Generated
+func gen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	typeNames := flags.String("type", "", "comma separated model struct names, default every struct whose first column field is Id")
+	output := flags.String("o", "px_gen.go", "output file name, in the package directory")
+	flags.Parse(args)
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	//parse
+	pkgName, files, e := parsePackage(dir, *output)
+	if e != nil {
+		return e
+	}
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(*typeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted[name] = true
+		}
+	}
+
+	models := []genModel{}
+	imports := make(map[string]string) // path by name, of the packages used by column types
+	for _, file := range files {
+		fileImports := importsOf(file)
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+				if len(wanted) > 0 && !wanted[ts.Name.Name] {
+					continue
+				}
+				model, e := toGenModel(ts.Name.Name, st)
+				if e != nil {
+					if len(wanted) > 0 {
+						return e
+					}
+					continue
+				}
+				if len(wanted) == 0 && (len(model.columns) == 0 || model.columns[0].dbTag != "id") {
+					continue
+				}
+				for _, name := range packagesOf(st) {
+					path, ok := fileImports[name]
+					if !ok {
+						return errors.New("type " + ts.Name.Name + ": unknown package " + name)
+					}
+					imports[name] = path
+				}
+				delete(wanted, ts.Name.Name)
+				models = append(models, model)
+			}
+		}
+	}
+	for name := range wanted {
+		return errors.New("struct type " + name + " not found in " + dir)
+	}
+	if len(models) == 0 {
+		return errors.New("no model struct found in " + dir)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].name < models[j].name })
+
+	//write
+	src, e := format.Source(genSource(pkgName, imports, models))
+	if e != nil {
+		return e
+	}
+	return os.WriteFile(filepath.Join(dir, *output), src, 0644)
+}
+
+// parsePackage parses the non-test Go files of dir, except the output file
+func parsePackage(dir, output string) (string, []*ast.File, error) {
+	entries, e := os.ReadDir(dir)
+	if e != nil {
+		return "", nil, e
+	}
+	fset := token.NewFileSet()
+	pkgName := ""
+	files := []*ast.File{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		file, e := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if e != nil {
+			return "", nil, e
+		}
+		if pkgName != "" && file.Name.Name != pkgName {
+			continue
+		}
+		pkgName = file.Name.Name
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return "", nil, errors.New("no Go file in " + dir)
+	}
+	return pkgName, files, nil
+}
+
+// toGenModel lists the columns of a struct the way px.NewBaseModel does
+func toGenModel(name string, st *ast.StructType) (genModel, error) {
+	model := genModel{name: name}
+	for _, field := range st.Fields.List {
+		if field.Tag != nil {
+			tag, e := strconv.Unquote(field.Tag.Value)
+			if e != nil {
+				return model, errors.New("type " + name + ": invalid tag " + field.Tag.Value)
+			}
+			if reflect.StructTag(tag).Get("px") == "-" {
+				continue
+			}
+		}
+		goType := types.ExprString(field.Type)
+		names := []string{}
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		if len(names) == 0 {
+			// embedded field, named after its type
+			embedded := strings.TrimPrefix(goType, "*")
+			names = append(names, embedded[strings.LastIndex(embedded, ".")+1:])
+		}
+		for _, fieldName := range names {
+			model.columns = append(model.columns, genColumn{field: fieldName, dbTag: strcase.ToSnake(fieldName), goType: goType})
+		}
+	}
+	return model, nil
+}
+
+// importsOf returns the import paths of a file by package name
+func importsOf(file *ast.File) map[string]string {
+	out := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		out[name] = path
+	}
+	return out
+}
+
+// packagesOf returns the package names referenced by the column field types of st
+func packagesOf(st *ast.StructType) []string {
+	out := []string{}
+	ast.Inspect(st, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			if reflect.StructTag(tag).Get("px") == "-" {
+				return false
+			}
+		}
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				out = append(out, ident.Name)
+			}
+		}
+		return true
+	})
+	return out
+}
+
+func genSource(pkgName string, imports map[string]string, models []genModel) []byte {
+	builder := new(strings.Builder)
+	builder.WriteString("// Code generated by px gen. DO NOT EDIT.\n\n")
+	builder.WriteString("package " + pkgName + "\n\n")
+
+	names := []string{}
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	builder.WriteString("import (\n")
+	for _, name := range names {
+		path := imports[name]
+		if path[strings.LastIndex(path, "/")+1:] == name {
+			builder.WriteString(strconv.Quote(path) + "\n")
+		} else {
+			builder.WriteString(name + " " + strconv.Quote(path) + "\n")
+		}
+	}
+	builder.WriteString("\n\"github.com/stevenzack/px\"\n)\n")
+
+	for _, m := range models {
+		//constants
+		builder.WriteString("\nconst (\n")
+		builder.WriteString(m.name + "Table = " + strconv.Quote(px.ToTableName(m.name)) + "\n")
+		for _, c := range m.columns {
+			builder.WriteString(m.name + "Column" + c.field + " = " + strconv.Quote(c.dbTag) + "\n")
+		}
+		builder.WriteString(")\n")
+
+		//px.Generated
+		columns, pointers, values := []string{}, []string{}, []string{}
+		for _, c := range m.columns {
+			columns = append(columns, m.name+"Column"+c.field)
+			pointers = append(pointers, "&v."+c.field)
+			values = append(values, "v."+c.field)
+		}
+		builder.WriteString("\nfunc (v *" + m.name + ") PxColumns() []string {\nreturn []string{" + strings.Join(columns, ", ") + "}\n}\n")
+		builder.WriteString("\nfunc (v *" + m.name + ") PxColumnPointers() []any {\nreturn []any{" + strings.Join(pointers, ", ") + "}\n}\n")
+		builder.WriteString("\nfunc (v *" + m.name + ") PxColumnValues() []any {\nreturn []any{" + strings.Join(values, ", ") + "}\n}\n")
+
+		//scan
+		builder.WriteString("\n// Scan" + m.name + " scans a row selecting every column of " + m.name + " in field order, as GetSelectSQL does\n")
+		builder.WriteString("func Scan" + m.name + "(row interface{ Scan(dest ...any) error }) (*" + m.name + ", error) {\n")
+		builder.WriteString("v := new(" + m.name + ")\nif e := row.Scan(v.PxColumnPointers()...); e != nil {\nreturn nil, e\n}\nreturn v, nil\n}\n")
+
+		//conditions
+		for _, c := range m.columns {
+			prefix := m.name + c.field
+			builder.WriteString("\nfunc " + prefix + "Eq(x " + c.goType + ") px.Cond { return px.Eq(" + m.name + "Column" + c.field + ", x) }\n")
+			builder.WriteString("func " + prefix + "In(xs []" + c.goType + ") px.Cond { return px.In(" + m.name + "Column" + c.field + ", xs) }\n")
+		}
+	}
+	return []byte(builder.String())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+func TestGen(t *testing.T) {
+	dir := t.TempDir()
+	src, e := os.ReadFile(filepath.Join("testdata", "gen", "model.go"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = os.WriteFile(filepath.Join(dir, "model.go"), src, 0644); e != nil {
+		t.Fatal(e)
+	}
+	if e = gen([]string{dir}); e != nil {
+		t.Fatal(e)
+	}
+	got, e := os.ReadFile(filepath.Join(dir, "px_gen.go"))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	golden := filepath.Join("testdata", "gen", "px_gen.go.golden")
+	if *update {
+		if e = os.WriteFile(golden, got, 0644); e != nil {
+			t.Fatal(e)
+		}
+	}
+	want, e := os.ReadFile(golden)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(got) != string(want) {
+		t.Errorf("px gen output differs from %s:\n%s", golden, got)
+	}
+}
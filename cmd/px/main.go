@@ -0,0 +1,72 @@
+// Command px is the command line tool of the px package
+//
+//	px gen [-type User,Order] [-o px_gen.go] [dir]
+//	px reverse [-dsn postgres://...] [-schema public] [-package models] [-o models.go] [table...]
+//	px diff|plan|apply -models ./models [-dsn postgres://...] [-drop]
+//	px describe|indexes [-dsn postgres://...] [-schema public] <table>
+//
+// diff, plan and apply load the models that the -models package registers with px.Register in its init functions
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/stevenzack/px/pxcli"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var e error
+	switch os.Args[1] {
+	case "gen":
+		e = gen(os.Args[2:])
+	case "reverse":
+		e = reverse(os.Args[2:])
+	case "diff", "plan", "apply", "describe", "indexes":
+		models, args := modelsFlag(os.Args[1:])
+		if models == "" {
+			if os.Args[1] != "describe" && os.Args[1] != "indexes" {
+				e = errors.New("missing -models, the package registering the models with px.Register")
+				break
+			}
+			var differs bool
+			differs, e = pxcli.Run(args)
+			if e == nil && differs {
+				os.Exit(1)
+			}
+			break
+		}
+		var code int
+		code, e = runModels(models, args)
+		if e == nil && code != 0 {
+			os.Exit(code)
+		}
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintln(os.Stderr, "px: unknown command '"+os.Args[1]+"'")
+		usage()
+		os.Exit(2)
+	}
+	if e != nil {
+		log.Fatal("px " + os.Args[1] + ": " + e.Error())
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: px <command> [arguments]
+
+commands:
+  gen        generate column constants, scan functions and typed conditions for model structs
+  reverse    generate model structs from existing tables
+`+pxcli.Usage)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// modelsFlag removes '-models package' from args, returning the package
+func modelsFlag(args []string) (string, []string) {
+	out := []string{}
+	models := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "models" {
+			out = append(out, arg)
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		models = value
+	}
+	return models, out
+}
+
+// runModels runs the schema command args in a temporary main package that imports models, whose init functions
+// register the models with px.Register. It returns the command's exit code
+func runModels(models string, args []string) (int, error) {
+	out, e := exec.Command("go", "list", "-f", "{{.ImportPath}}", models).Output()
+	if e != nil {
+		if exitError, ok := e.(*exec.ExitError); ok {
+			return 0, errors.New("go list " + models + ": " + strings.TrimSpace(string(exitError.Stderr)))
+		}
+		return 0, e
+	}
+	importPath := strings.TrimSpace(string(out))
+
+	// inside the current module so that the models package resolves
+	dir, e := os.MkdirTemp(".", "pxrun")
+	if e != nil {
+		return 0, e
+	}
+	defer os.RemoveAll(dir)
+	src := "package main\n\nimport (\n\t_ " + strconv.Quote(importPath) + "\n\n\t\"github.com/stevenzack/px/pxcli\"\n)\n\nfunc main() { pxcli.Main() }\n"
+	e = os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644)
+	if e != nil {
+		return 0, e
+	}
+
+	cmd := exec.Command("go", append([]string{"run", "./" + filepath.ToSlash(dir)}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	e = cmd.Run()
+	if exitError, ok := e.(*exec.ExitError); ok {
+		return exitError.ExitCode(), nil
+	}
+	return 0, e
+}
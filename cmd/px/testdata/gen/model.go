@@ -0,0 +1,26 @@
+package model
+
+import (
+	"sync"
+	"time"
+
+	pq "github.com/lib/pq"
+)
+
+type Meta struct {
+	Source string
+}
+
+type User struct {
+	Id         uint32
+	Name       string
+	Tags       pq.StringArray
+	Cache      *sync.Map `px:"-"`
+	CreateTime time.Time
+	Meta
+}
+
+// Options has no Id column, so it isn't a model
+type Options struct {
+	Limit int
+}
@@ -2,19 +2,39 @@ package px
 
 import (
 	"context"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Column struct {
-	ColumnName string `db:"column_name"`
-	DataType   string `db:"data_type"`
-	IsNullable string `db:"is_nullable"`
+	ColumnName             string   `db:"column_name"`
+	DataType               string   `db:"data_type"`
+	IsNullable             string   `db:"is_nullable"`
+	UdtName                string   `db:"udt_name"`                 // int4, varchar, _int8 for bigint[] etc.
+	CharacterMaximumLength *int32   `db:"character_maximum_length"` // n of varchar(n) and char(n)
+	NumericPrecision       *int32   `db:"numeric_precision"`
+	NumericScale           *int32   `db:"numeric_scale"`
+	ColumnDefault          *string  `db:"column_default"`
+	OrdinalPosition        int32    `db:"ordinal_position"`
+	Comment                *string  `db:"comment"`
+	Checks                 []string `db:"checks"` // definitions of the check constraints on this column alone
 }
 
+var (
+	castRegexp       = regexp.MustCompile(`::[a-z ]+(\[\])?$`)
+	localCheckRegexp = regexp.MustCompile(` check \(.*\)$`)
+)
+
 // select column_name,data_type from information_schema.columns where table_catalog='langenius' and table_schema='public' and table_name='student'
 func DescTable(pool *pgxpool.Pool, database, schema, tableName string) ([]Column, error) {
-	rows, e := pool.Query(context.Background(), `select column_name,data_type,is_nullable from information_schema.columns where table_catalog=$1 and table_schema=$2 and table_name=$3`, database, schema, tableName)
+	rows, e := pool.Query(context.Background(), `select c.column_name,c.data_type,c.is_nullable,c.udt_name,c.character_maximum_length,c.numeric_precision,c.numeric_scale,c.column_default,c.ordinal_position,
+		col_description(to_regclass(quote_ident(c.table_schema)||'.'||quote_ident(c.table_name)),c.ordinal_position),
+		array(select pg_get_constraintdef(k.oid) from pg_constraint k where k.conrelid=to_regclass(quote_ident(c.table_schema)||'.'||quote_ident(c.table_name)) and k.contype='c' and k.conkey=array[c.ordinal_position::smallint] order by k.conname)
+		from information_schema.columns c where c.table_catalog=$1 and c.table_schema=$2 and c.table_name=$3 order by c.ordinal_position`, database, schema, tableName)
 	if e != nil {
 		return nil, e
 	}
@@ -22,7 +42,7 @@ func DescTable(pool *pgxpool.Pool, database, schema, tableName string) ([]Column
 	out := []Column{}
 	for rows.Next() {
 		v := Column{}
-		e = rows.Scan(&v.ColumnName, &v.DataType, &v.IsNullable)
+		e = rows.Scan(&v.ColumnName, &v.DataType, &v.IsNullable, &v.UdtName, &v.CharacterMaximumLength, &v.NumericPrecision, &v.NumericScale, &v.ColumnDefault, &v.OrdinalPosition, &v.Comment, &v.Checks)
 		if e != nil {
 			break
 		}
@@ -37,3 +57,95 @@ func DescTable(pool *pgxpool.Pool, database, schema, tableName string) ([]Column
 
 	return out, nil
 }
+
+// TypeString returns the column type as written in DDL, like 'character varying(64)' or 'bigint[]'
+func (c Column) TypeString() string {
+	if c.DataType == "ARRAY" {
+		return strings.TrimPrefix(c.UdtName, "_") + "[]"
+	}
+	if c.CharacterMaximumLength != nil {
+		return c.DataType + "(" + strconv.Itoa(int(*c.CharacterMaximumLength)) + ")"
+	}
+	return c.DataType
+}
+
+// expectedColumn returns the information_schema data_type, udt_name and character length that a local pgType creates
+func expectedColumn(pgType string) (string, string, int) {
+	base := strings.TrimSpace(localCheckRegexp.ReplaceAllString(pgType, ""))
+	base, _, _ = strings.Cut(base, " not null")
+	base, _, _ = strings.Cut(base, " default ")
+	switch {
+	case strings.HasSuffix(base, "[]"):
+		udt := map[string]string{"integer": "_int4", "bigint": "_int8", "text": "_text", "boolean": "_bool"}[strings.TrimSuffix(base, "[]")]
+		return "ARRAY", udt, 0
+	case strings.HasPrefix(base, "varchar("), strings.HasPrefix(base, "char("):
+		name, n, _ := strings.Cut(strings.TrimSuffix(base, ")"), "(")
+		length, _ := strconv.Atoi(n)
+		if name == "varchar" {
+			return "character varying", "varchar", length
+		}
+		return "character", "bpchar", length
+	}
+	switch base {
+	case "serial":
+		return "integer", "int4", 0
+	case "smallserial":
+		return "smallint", "int2", 0
+	case "bigserial":
+		return "bigint", "int8", 0
+	}
+	return base, "", 0
+}
+
+// localDefault returns the default expression of a local pgType, "" if none
+func localDefault(pgType string) string {
+	_, def, ok := strings.Cut(localCheckRegexp.ReplaceAllString(pgType, ""), " default ")
+	if !ok {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(def), "'")
+}
+
+// remoteDefault strips the casts and quotes postgres adds to a column default
+func remoteDefault(def string) string {
+	for castRegexp.MatchString(def) {
+		def = castRegexp.ReplaceAllString(def, "")
+	}
+	return strings.Trim(def, "'")
+}
+
+// columnConflicts compares a local column of pgType with its remote column
+func columnConflicts(dbTag, pgType string, remote Column) []string {
+	out := []string{}
+	dataType, udtName, length := expectedColumn(pgType)
+	if dataType != remote.DataType || (udtName != "" && udtName != remote.UdtName) {
+		out = append(out, "Found local field "+dbTag+"'s type '"+pgType+"' doesn't match remote column type:"+remote.TypeString())
+	} else if length > 0 && (remote.CharacterMaximumLength == nil || int(*remote.CharacterMaximumLength) != length) {
+		out = append(out, "Found local field "+dbTag+"'s length "+strconv.Itoa(length)+" doesn't match remote column type:"+remote.TypeString())
+	}
+	if strings.Contains(pgType, "not null") != (remote.IsNullable == "NO") {
+		out = append(out, "Found local field "+dbTag+"'s nullability '"+pgType+"' doesn't match remote column nullability :"+remote.IsNullable)
+	}
+
+	//default, serial columns default to their sequence. px inserts every other column, so a missing remote default isn't a conflict
+	remoteDef := ""
+	if remote.ColumnDefault != nil {
+		remoteDef = remoteDefault(*remote.ColumnDefault)
+	}
+	switch def := localDefault(pgType); {
+	case strings.Contains(pgType, "serial"):
+		if !strings.HasPrefix(remoteDef, "nextval(") {
+			out = append(out, "Found local field "+dbTag+" is serial but remote column has no sequence default")
+		}
+	case remoteDef == "", dataType == "timestamp with time zone":
+		// a timestamp default is rendered in the server's time zone, not compared
+	case def != remoteDef:
+		out = append(out, "Found local field "+dbTag+"'s default '"+def+"' doesn't match remote column default:"+remoteDef)
+	}
+
+	//check, only the unsigned one px declares
+	if localCheckRegexp.MatchString(pgType) && !slices.ContainsFunc(remote.Checks, func(check string) bool { return isUnsignedCheck(check, dbTag) }) {
+		out = append(out, "Found local field "+dbTag+"'s check constraint '"+pgType+"' missing on remote column checks:"+strings.Join(remote.Checks, ","))
+	}
+	return out
+}
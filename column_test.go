@@ -0,0 +1,91 @@
+package px
+
+import "testing"
+
+func TestExpectedColumn(t *testing.T) {
+	tests := []struct {
+		pgType   string
+		dataType string
+		udtName  string
+		length   int
+	}{
+		{"bigint not null default 0", "bigint", "", 0},
+		{"integer not null default 0 check ( age>-1 )", "integer", "", 0},
+		{"serial not null", "integer", "int4", 0},
+		{"bigserial not null", "bigint", "int8", 0},
+		{"smallserial not null", "smallint", "int2", 0},
+		{"varchar(36) not null default ''", "character varying", "varchar", 36},
+		{"varchar(100)", "character varying", "varchar", 100},
+		{"char(8) not null", "character", "bpchar", 8},
+		{"text[]", "ARRAY", "_text", 0},
+		{"bigint[]", "ARRAY", "_int8", 0},
+		{"timestamp with time zone not null default '0001-01-01 00:00:00'", "timestamp with time zone", "", 0},
+		{"double precision not null default 0", "double precision", "", 0},
+	}
+	for _, tt := range tests {
+		dataType, udtName, length := expectedColumn(tt.pgType)
+		if dataType != tt.dataType || udtName != tt.udtName || length != tt.length {
+			t.Errorf("expectedColumn(%q) = %q, %q, %d, want %q, %q, %d", tt.pgType, dataType, udtName, length, tt.dataType, tt.udtName, tt.length)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	locals := []struct{ pgType, want string }{
+		{"bigint not null default 0", "0"},
+		{"text not null default ''", ""},
+		{"boolean not null default false", "false"},
+		{"integer not null default 0 check ( age>-1 )", "0"},
+		{"text", ""},
+	}
+	for _, tt := range locals {
+		if got := localDefault(tt.pgType); got != tt.want {
+			t.Errorf("localDefault(%q) = %q, want %q", tt.pgType, got, tt.want)
+		}
+	}
+
+	remotes := []struct{ def, want string }{
+		{"0", "0"},
+		{"''::text", ""},
+		{"''::character varying", ""},
+		{"false", "false"},
+		{"'{}'::text[]", "{}"},
+		{"'0001-01-01 00:00:00+00'::timestamp with time zone", "0001-01-01 00:00:00+00"},
+		{"nextval('users_id_seq'::regclass)", "nextval('users_id_seq'::regclass)"},
+	}
+	for _, tt := range remotes {
+		if got := remoteDefault(tt.def); got != tt.want {
+			t.Errorf("remoteDefault(%q) = %q, want %q", tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestColumnConflicts(t *testing.T) {
+	str := func(s string) *string { return &s }
+	length := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name   string
+		pgType string
+		remote Column
+		want   int
+	}{
+		{"same varchar", "varchar(36) not null default ''", Column{DataType: "character varying", UdtName: "varchar", CharacterMaximumLength: length(36), IsNullable: "NO", ColumnDefault: str("''::character varying")}, 0},
+		{"longer varchar", "varchar(36) not null default ''", Column{DataType: "character varying", UdtName: "varchar", CharacterMaximumLength: length(64), IsNullable: "NO", ColumnDefault: str("''::character varying")}, 1},
+		{"nullable", "bigint not null default 0", Column{DataType: "bigint", IsNullable: "YES", ColumnDefault: str("0")}, 1},
+		{"serial", "serial not null", Column{DataType: "integer", UdtName: "int4", IsNullable: "NO", ColumnDefault: str("nextval('users_id_seq'::regclass)")}, 0},
+		{"serial without sequence", "serial not null", Column{DataType: "integer", UdtName: "int4", IsNullable: "NO"}, 1},
+		{"timestamp default presence", "timestamp with time zone not null default '0001-01-01 00:00:00'", Column{DataType: "timestamp with time zone", IsNullable: "NO", ColumnDefault: str("'0001-01-01 00:00:00+00'::timestamp with time zone")}, 0},
+		{"timestamp without default", "timestamp with time zone not null default '0001-01-01 00:00:00'", Column{DataType: "timestamp with time zone", IsNullable: "NO"}, 0},
+		{"without default", "boolean not null default false", Column{DataType: "boolean", IsNullable: "NO"}, 0},
+		{"other default", "bigint not null default 0", Column{DataType: "bigint", IsNullable: "NO", ColumnDefault: str("5")}, 1},
+		{"missing check", "integer not null default 0 check ( age>-1 )", Column{DataType: "integer", IsNullable: "NO", ColumnDefault: str("0")}, 1},
+		{"other check", "integer not null default 0 check ( age>-1 )", Column{DataType: "integer", IsNullable: "NO", ColumnDefault: str("0"), Checks: []string{"CHECK ((age < 200))"}}, 1},
+		{"unsigned check", "integer not null default 0 check ( age>-1 )", Column{DataType: "integer", IsNullable: "NO", ColumnDefault: str("0"), Checks: []string{"CHECK ((age > '-1'::integer))", "CHECK ((age < 200))"}}, 0},
+		{"type", "text", Column{DataType: "integer", IsNullable: "YES"}, 1},
+	}
+	for _, tt := range tests {
+		if got := columnConflicts("age", tt.pgType, tt.remote); len(got) != tt.want {
+			t.Errorf("%s: columnConflicts = %q, want %d conflicts", tt.name, got, tt.want)
+		}
+	}
+}
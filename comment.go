@@ -0,0 +1,72 @@
+package px
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TableCommenter is implemented by a model type documenting its table, like
+//
+//	func (User) TableComment() string { return "registered users" }
+//
+// Schema sync applies it, and the comment tags of the fields, with comment on
+type TableCommenter interface {
+	TableComment() string
+}
+
+// tableComment returns the comment of T's TableComment method, "" without one
+func (b *BaseModel[T]) tableComment() string {
+	if c, ok := any(new(T)).(TableCommenter); ok {
+		return c.TableComment()
+	}
+	return ""
+}
+
+// DescTableComment returns the comment on a table, nil if it has none
+func DescTableComment(pool *pgxpool.Pool, schema, tableName string) (*string, error) {
+	var comment *string
+	e := pool.QueryRow(context.Background(), `select obj_description(c.oid,'pg_class') from pg_class c join pg_namespace n on n.oid=c.relnamespace where n.nspname=$1 and c.relname=$2`, schema, tableName).Scan(&comment)
+	if e != nil {
+		return nil, e
+	}
+	return comment, nil
+}
+
+// commentChanges returns the comment on statements for the local comments differing from the remote ones.
+// A remote comment without a local one is removed
+func (b *BaseModel[T]) commentChanges(remoteTable *string, remoteColumns map[string]Column) []SchemaChange {
+	out := []SchemaChange{}
+	if local := b.tableComment(); !sameComment(local, remoteTable) {
+		out = append(out, SchemaChange{Kind: Comment, Name: b.TableName, SQL: `comment on table ` + b.Schema + `.` + b.TableName + ` is ` + commentLiteral(local)})
+	}
+	for i, local := range b.comments {
+		if sameComment(local, remoteColumns[b.dbTags[i]].Comment) {
+			continue
+		}
+		out = append(out, SchemaChange{Kind: Comment, Name: b.dbTags[i], SQL: `comment on column ` + b.Schema + `.` + b.TableName + `.` + b.dbTags[i] + ` is ` + commentLiteral(local)})
+	}
+	return out
+}
+
+// sameComment reports whether the local comment, "" for none, is the remote one, nil for none
+func sameComment(local string, remote *string) bool {
+	if remote == nil {
+		return local == ""
+	}
+	return local == *remote
+}
+
+// commentLiteral returns the comment as a SQL string literal, null to remove it if it's empty
+func commentLiteral(comment string) string {
+	if comment == "" {
+		return "null"
+	}
+	return quoteLiteral(comment)
+}
+
+// quoteLiteral quotes s as a SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
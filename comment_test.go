@@ -0,0 +1,87 @@
+package px
+
+import (
+	"reflect"
+	"testing"
+)
+
+type commentedUser struct{ Id uint32 }
+
+func (commentedUser) TableComment() string { return "the users' table" }
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct{ s, want string }{
+		{"login", "'login'"},
+		{"it's", "'it''s'"},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := quoteLiteral(tt.s); got != tt.want {
+			t.Errorf("quoteLiteral(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCommentChanges(t *testing.T) {
+	str := func(s string) *string { return &s }
+	commented := &BaseModel[commentedUser]{Schema: "public", TableName: "users", dbTags: []string{"id", "email", "name"}, comments: []string{"", "login", ""}}
+	plain := &BaseModel[struct{}]{Schema: "public", TableName: "users", dbTags: []string{"id", "email"}, comments: []string{"", ""}}
+	tests := []struct {
+		name    string
+		changes func(remoteTable *string, remoteColumns map[string]Column) []SchemaChange
+		table   *string
+		columns map[string]Column
+		want    []string
+	}{
+		{
+			name:    "created table",
+			changes: commented.commentChanges,
+			want: []string{
+				"comment on table public.users is 'the users'' table'",
+				"comment on column public.users.email is 'login'",
+			},
+		},
+		{
+			name:    "in sync",
+			changes: commented.commentChanges,
+			table:   str("the users' table"),
+			columns: map[string]Column{"email": {Comment: str("login")}},
+		},
+		{
+			name:    "changed",
+			changes: commented.commentChanges,
+			table:   str("users"),
+			columns: map[string]Column{"email": {Comment: str("mail")}},
+			want: []string{
+				"comment on table public.users is 'the users'' table'",
+				"comment on column public.users.email is 'login'",
+			},
+		},
+		{
+			name:    "removed",
+			changes: plain.commentChanges,
+			table:   str("users"),
+			columns: map[string]Column{"email": {Comment: str("login")}},
+			want: []string{
+				"comment on table public.users is null",
+				"comment on column public.users.email is null",
+			},
+		},
+		{
+			name:    "none",
+			changes: plain.commentChanges,
+		},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, change := range tt.changes(tt.table, tt.columns) {
+			if change.Kind != Comment {
+				t.Errorf("%s: kind %q", tt.name, change.Kind)
+			}
+			got = append(got, change.SQL)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
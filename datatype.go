@@ -5,8 +5,6 @@ import (
 	"reflect"
 	"strconv"
 	"time"
-
-	"github.com/stevenzack/tools/strToolkit"
 )
 
 func ToPostgreType(t reflect.Type, dbTag string, length, limit int) (string, error) {
@@ -126,23 +124,6 @@ func ToPostgreType(t reflect.Type, dbTag string, length, limit int) (string, err
 	return "", errors.New("unsupport field type:" + t.String() + ",kind=" + t.Kind().String())
 }
 
-func toPgPrimitiveType(dbType string) string {
-	dbType = strToolkit.SubBefore(dbType, " ", dbType)
-	dbType = strToolkit.SubBefore(dbType, "(", dbType)
-	switch dbType {
-	case "serial":
-		dbType = "integer"
-	case "smallserial":
-		dbType = "smallint"
-	case "bigserial":
-		dbType = "bigint"
-	case "char", "varchar":
-		dbType = "character"
-	}
-
-	return dbType
-}
-
 func String(s string) *string {
 	if s == "" {
 		return nil
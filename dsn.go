@@ -2,6 +2,7 @@ package px
 
 import (
 	"errors"
+	"os"
 	"strings"
 
 	"github.com/stevenzack/tools/strToolkit"
@@ -27,3 +28,13 @@ func FormatDsn(m map[string]string) string {
 	}
 	return buf.String()
 }
+
+// EnvDsn returns a dsn for the $PGDATABASE database, or "" if it isn't set. pgx takes the host, port, user,
+// password and sslmode from the PGHOST, PGPORT, PGUSER, PGPASSWORD and PGSSLMODE environment variables
+func EnvDsn() string {
+	database := os.Getenv("PGDATABASE")
+	if database == "" {
+		return ""
+	}
+	return "postgres:///" + database
+}
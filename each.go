@@ -0,0 +1,204 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errStopIteration = errors.New("stop iteration")
+	cursorSeq        atomic.Int64
+)
+
+// Each scans the documents that match 'where' condition one at a time, without loading them all into memory.
+// It stops at the first error returned by fn
+func (b *BaseModel[T]) Each(fn func(v *T) error, where string, args ...any) error {
+	return b.EachContext(context.Background(), fn, where, args...)
+}
+
+// EachContext is Each with a context, cancelling it stops the iteration.
+// In a transaction (see WithTx), AfterFind hooks can't run while the rows are read from its connection, so a T with
+// an AfterFind hook has the documents loaded first, then hooked and passed to fn. Use EachCursor to keep batches small
+func (b *BaseModel[T]) EachContext(ctx context.Context, fn func(v *T) error, where string, args ...any) error {
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return e
+	}
+	_, query := b.GetSelectSQL()
+	query = query + where
+
+	rows, e := b.Executor().Query(ctx, query, args...)
+	if e != nil {
+		return b.toError(e, query)
+	}
+	if _, hooked := any(new(T)).(AfterFindHook); !hooked || b.tx == nil {
+		// the pool gives hooks another connection
+		_, e = b.scanEach(ctx, rows, query, b.withAfterFind(fn))
+		return e
+	}
+
+	vs := []*T{}
+	_, e = b.scanEach(ctx, rows, query, func(v *T) error {
+		vs = append(vs, v)
+		return nil
+	})
+	if e != nil {
+		return e
+	}
+	fn = b.withAfterFind(fn)
+	for _, v := range vs {
+		e = fn(v)
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// Iter returns an iterator over the documents that match 'where' condition, for use with range:
+//
+//	for v, e := range model.Iter("") { ... }
+//
+// A query error is yielded once, with a zero T
+func (b *BaseModel[T]) Iter(where string, args ...any) iter.Seq2[T, error] {
+	return b.IterContext(context.Background(), where, args...)
+}
+
+// IterContext is Iter with a context
+func (b *BaseModel[T]) IterContext(ctx context.Context, where string, args ...any) iter.Seq2[T, error] {
+	return b.iter(func(fn func(v *T) error) error {
+		return b.EachContext(ctx, fn, where, args...)
+	})
+}
+
+// EachCursor is EachContext using a server side cursor, fetching fetchSize rows per round trip.
+// It runs in the model's transaction (see WithTx), or in a new transaction
+func (b *BaseModel[T]) EachCursor(ctx context.Context, fetchSize int, fn func(v *T) error, where string, args ...any) error {
+	if fetchSize < 1 {
+		return errors.New("invalid fetch size:" + strconv.Itoa(fetchSize))
+	}
+	where, args, e := b.bindWhere(where, args)
+	if e != nil {
+		return e
+	}
+	_, query := b.GetSelectSQL()
+	query = query + where
+
+	beginner, ok := b.Executor().(interface {
+		Begin(ctx context.Context) (pgx.Tx, error)
+	})
+	if !ok {
+		return errors.New("executor doesn't support transactions, which cursors require")
+	}
+	tx, e := beginner.Begin(ctx)
+	if e != nil {
+		return e
+	}
+	defer tx.Rollback(context.Background())
+
+	cursor := "px_cursor_" + strconv.FormatInt(cursorSeq.Add(1), 10)
+	declare := `declare ` + cursor + ` no scroll cursor for ` + query
+	_, e = tx.Exec(ctx, declare, args...)
+	if e != nil {
+		return b.toError(e, declare)
+	}
+
+	// hooks run in the cursor's transaction, once a batch is fetched and the connection is free
+	fn = b.WithTx(tx).withAfterFind(fn)
+	fetch := `fetch forward ` + strconv.Itoa(fetchSize) + ` from ` + cursor
+	for {
+		rows, e := tx.Query(ctx, fetch)
+		if e != nil {
+			return b.toError(e, fetch)
+		}
+		batch := make([]*T, 0, fetchSize)
+		n, e := b.scanEach(ctx, rows, fetch, func(v *T) error {
+			batch = append(batch, v)
+			return nil
+		})
+		if e != nil {
+			return e
+		}
+		for _, v := range batch {
+			e = fn(v)
+			if e != nil {
+				return e
+			}
+		}
+		if n < fetchSize {
+			break
+		}
+	}
+
+	_, e = tx.Exec(ctx, `close `+cursor)
+	if e != nil {
+		return b.toError(e, `close `+cursor)
+	}
+	return tx.Commit(ctx)
+}
+
+// IterCursor is an iterator over EachCursor
+func (b *BaseModel[T]) IterCursor(ctx context.Context, fetchSize int, where string, args ...any) iter.Seq2[T, error] {
+	return b.iter(func(fn func(v *T) error) error {
+		return b.EachCursor(ctx, fetchSize, fn, where, args...)
+	})
+}
+
+func (b *BaseModel[T]) iter(each func(fn func(v *T) error) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		e := each(func(v *T) error {
+			if !yield(*v, nil) {
+				return errStopIteration
+			}
+			return nil
+		})
+		if e != nil && !errors.Is(e, errStopIteration) {
+			var zero T
+			yield(zero, e)
+		}
+	}
+}
+
+// scanEach scans rows selecting every column one at a time into fn. It returns the number of rows scanned.
+// Errors of fn are returned as is
+func (b *BaseModel[T]) scanEach(ctx context.Context, rows pgx.Rows, query string, fn func(v *T) error) (int, error) {
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		if e := ctx.Err(); e != nil {
+			return n, e
+		}
+		v := new(T)
+		e := rows.Scan(b.fieldArgs(v)...)
+		if e != nil {
+			return n, b.toError(e, query)
+		}
+		n++
+		e = fn(v)
+		if e != nil {
+			return n, e
+		}
+	}
+	rows.Close()
+	if e := rows.Err(); e != nil {
+		return n, b.toError(e, query)
+	}
+	return n, nil
+}
+
+// withAfterFind runs AfterFind on each document before fn
+func (b *BaseModel[T]) withAfterFind(fn func(v *T) error) func(v *T) error {
+	return func(v *T) error {
+		e := b.afterFind(v)
+		if e != nil {
+			return e
+		}
+		return fn(v)
+	}
+}
@@ -0,0 +1,145 @@
+package px
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stevenzack/tools/strToolkit"
+)
+
+var (
+	// ErrNotFound is returned when no row matches. It is sql.ErrNoRows, so existing errors.Is(e, sql.ErrNoRows) checks keep working
+	ErrNotFound            = sql.ErrNoRows
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrCheckViolation      = errors.New("check violation")
+	ErrSerialization       = errors.New("serialization failure")
+	// ErrStaleObject is returned by Update when the row's px:"version" no longer matches, i.e. it was modified concurrently
+	ErrStaleObject = errors.New("stale object: row was modified or deleted by another update")
+)
+
+// postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgCodeUniqueViolation      = "23505"
+	pgCodeForeignKeyViolation  = "23503"
+	pgCodeCheckViolation       = "23514"
+	pgCodeSerializationFailure = "40001"
+	pgCodeDeadlockDetected     = "40P01"
+)
+
+// ConstraintError wraps a *pgconn.PgError raised by a constraint or serialization failure.
+// errors.Is(e, ErrUniqueViolation) etc. reports its Kind
+type ConstraintError struct {
+	Kind       error
+	Table      string
+	Constraint string
+	Column     string // db column, empty if it can't be resolved
+	Field      string // Go struct field of Column, empty if it can't be resolved
+	Query      string
+	PgError    *pgconn.PgError
+}
+
+func (c *ConstraintError) Error() string {
+	s := c.Kind.Error()
+	if c.Constraint != "" {
+		s += " on constraint " + c.Constraint
+	}
+	if c.Field != "" {
+		s += " (field " + c.Field + ")"
+	}
+	return s + ": " + c.PgError.Error() + ":" + c.Query
+}
+
+func (c *ConstraintError) Is(target error) bool {
+	return target == c.Kind
+}
+
+func (c *ConstraintError) Unwrap() error {
+	return c.PgError
+}
+
+// toError converts pgx errors into px errors: pgx.ErrNoRows becomes ErrNotFound, constraint violations become *ConstraintError,
+// and everything else is annotated with the query
+func (b *BaseModel[T]) toError(e error, query string) error {
+	if e == nil {
+		return nil
+	}
+	if errors.Is(e, pgx.ErrNoRows) || errors.Is(e, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(e, &pgErr) {
+		return fmt.Errorf("%w:%s", e, query)
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case pgCodeUniqueViolation:
+		kind = ErrUniqueViolation
+	case pgCodeForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case pgCodeCheckViolation:
+		kind = ErrCheckViolation
+	case pgCodeSerializationFailure, pgCodeDeadlockDetected:
+		kind = ErrSerialization
+	default:
+		return fmt.Errorf("%w:%s", e, query)
+	}
+
+	ce := &ConstraintError{
+		Kind:       kind,
+		Table:      pgErr.TableName,
+		Constraint: pgErr.ConstraintName,
+		Query:      query,
+		PgError:    pgErr,
+	}
+	if ce.Table == "" {
+		ce.Table = b.TableName
+	}
+	ce.Column = b.constraintColumn(pgErr)
+	ce.Field = b.fieldNameOf(ce.Column)
+	return ce
+}
+
+// constraintColumn resolves the column a constraint error refers to, using the reported column,
+// the 'Key (column)=(value)' detail, or the constraint name like users_phone_number_idx
+func (b *BaseModel[T]) constraintColumn(pgErr *pgconn.PgError) string {
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+	if strings.HasPrefix(pgErr.Detail, "Key (") {
+		column := strToolkit.SubBefore(strings.TrimPrefix(pgErr.Detail, "Key ("), ")", "")
+		if strToolkit.SliceContains(b.dbTags, column) {
+			return column
+		}
+	}
+	if pgErr.ConstraintName == "" {
+		return ""
+	}
+	if pgErr.ConstraintName == b.TableName+"_pkey" {
+		return b.dbTags[0]
+	}
+	column := convertIndexToFieldName(b.TableName, pgErr.ConstraintName)
+	for _, suffix := range []string{"_key", "_fkey", "_check"} {
+		column = strings.TrimSuffix(column, suffix)
+	}
+	if strToolkit.SliceContains(b.dbTags, column) {
+		return column
+	}
+	return ""
+}
+
+// fieldNameOf returns the Go struct field name of column dbTag
+func (b *BaseModel[T]) fieldNameOf(dbTag string) string {
+	for i, v := range b.dbTags {
+		if v == dbTag {
+			return b.Type.Field(b.fields[i]).Name
+		}
+	}
+	return ""
+}
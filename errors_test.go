@@ -0,0 +1,72 @@
+package px
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type errorUser struct {
+	Id          uint32
+	PhoneNumber string
+	CompanyId   uint32
+	Age         int32
+}
+
+func TestToError(t *testing.T) {
+	b, e := NewBaseModel[errorUser](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer b.Pool.Close()
+
+	tests := []struct {
+		name   string
+		err    error
+		kind   error // nil if not a *ConstraintError
+		column string
+		field  string
+	}{
+		{"unique detail", &pgconn.PgError{Code: "23505", ConstraintName: "error_users_phone_number_key", Detail: "Key (phone_number)=(1) already exists."}, ErrUniqueViolation, "phone_number", "PhoneNumber"},
+		{"unique index name", &pgconn.PgError{Code: "23505", ConstraintName: "error_users_phone_number_idx"}, ErrUniqueViolation, "phone_number", "PhoneNumber"},
+		{"primary key", &pgconn.PgError{Code: "23505", ConstraintName: "error_users_pkey"}, ErrUniqueViolation, "id", "Id"},
+		{"foreign key", &pgconn.PgError{Code: "23503", ConstraintName: "error_users_company_id_fkey"}, ErrForeignKeyViolation, "company_id", "CompanyId"},
+		{"check", &pgconn.PgError{Code: "23514", ConstraintName: "error_users_age_check"}, ErrCheckViolation, "age", "Age"},
+		{"reported column", &pgconn.PgError{Code: "23514", ColumnName: "age"}, ErrCheckViolation, "age", "Age"},
+		{"unknown constraint", &pgconn.PgError{Code: "23505", ConstraintName: "other_idx"}, ErrUniqueViolation, "", ""},
+		{"serialization", &pgconn.PgError{Code: "40001"}, ErrSerialization, "", ""},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, ErrSerialization, "", ""},
+		{"wrapped", fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23503", ConstraintName: "error_users_company_id_fkey"}), ErrForeignKeyViolation, "company_id", "CompanyId"},
+		{"not null", &pgconn.PgError{Code: "23502", ColumnName: "age"}, nil, "", ""},
+		{"other", errors.New("connection refused"), nil, "", ""},
+	}
+	for _, tt := range tests {
+		got := b.toError(tt.err, "query")
+		var ce *ConstraintError
+		if !errors.As(got, &ce) {
+			if tt.kind != nil {
+				t.Errorf("%s: %v is not a *ConstraintError", tt.name, got)
+			} else if !errors.Is(got, tt.err) {
+				t.Errorf("%s: %v doesn't wrap %v", tt.name, got, tt.err)
+			}
+			continue
+		}
+		if tt.kind == nil {
+			t.Errorf("%s: unexpected *ConstraintError %v", tt.name, ce)
+			continue
+		}
+		if !errors.Is(got, tt.kind) || ce.Column != tt.column || ce.Field != tt.field || ce.Table != "error_users" {
+			t.Errorf("%s: kind %v, column %q, field %q, table %q, want %v, %q, %q", tt.name, ce.Kind, ce.Column, ce.Field, ce.Table, tt.kind, tt.column, tt.field)
+		}
+	}
+
+	if got := b.toError(pgx.ErrNoRows, "query"); got != ErrNotFound {
+		t.Errorf("toError(pgx.ErrNoRows) = %v", got)
+	}
+	if b.toError(nil, "query") != nil {
+		t.Error("toError(nil) != nil")
+	}
+}
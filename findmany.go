@@ -0,0 +1,83 @@
+package px
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// idKeys converts ids (a slice) to a []id type of the first field for a '= any($1)' parameter, with the converted ids in order
+func (b *BaseModel[T]) idKeys(ids any) (any, []any, error) {
+	value := reflect.ValueOf(ids)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("ids must be a slice, got %T", ids)
+	}
+	idType := b.Type.Field(b.fields[0]).Type
+
+	keys := reflect.MakeSlice(reflect.SliceOf(idType), 0, value.Len())
+	list := make([]any, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		key, ok := relationKey(value.Index(i), idType)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid id %v for %s column %s", value.Index(i).Interface(), idType, b.dbTags[0])
+		}
+		keys = reflect.Append(keys, reflect.ValueOf(key))
+		list = append(list, key)
+	}
+	return keys.Interface(), list, nil
+}
+
+// FindMany finds the documents whose id is in ids (a slice of the id type), in no particular order
+func (b *BaseModel[T]) FindMany(ids any) ([]T, error) {
+	keys, list, e := b.idKeys(ids)
+	if e != nil {
+		return nil, e
+	}
+	if len(list) == 0 {
+		return []T{}, nil
+	}
+
+	_, query := b.GetSelectSQL()
+	query = query + b.scope(` where `+b.TableName+`.`+b.dbTags[0]+` = any($1)`)
+	return b.queryAll(query, []any{keys})
+}
+
+// FindManyOrdered is FindMany returning the documents in the order of ids, and the ids that were not found.
+// A repeated id yields the document once per occurrence
+func (b *BaseModel[T]) FindManyOrdered(ids any) ([]T, []any, error) {
+	_, list, e := b.idKeys(ids)
+	if e != nil {
+		return nil, nil, e
+	}
+	vs, e := b.FindMany(ids)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	byId := make(map[any]int, len(vs))
+	for i := range vs {
+		byId[b.field(reflect.ValueOf(&vs[i]).Elem(), 0).Interface()] = i
+	}
+	out := make([]T, 0, len(list))
+	missing := []any{}
+	for _, id := range list {
+		i, ok := byId[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		out = append(out, vs[i])
+	}
+	return out, missing, nil
+}
+
+// DeleteMany deletes the documents whose id is in ids, or marks them deleted if the model has a px:"softdelete" field
+func (b *BaseModel[T]) DeleteMany(ids any) (int64, error) {
+	keys, list, e := b.idKeys(ids)
+	if e != nil {
+		return 0, e
+	}
+	if len(list) == 0 {
+		return 0, nil
+	}
+	return b.deleteWhere(b.scope(` where `+b.dbTags[0]+` = any($1)`), []any{keys})
+}
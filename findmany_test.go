@@ -0,0 +1,72 @@
+package px
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type idUint struct {
+	Id   uint32
+	Name string
+}
+
+type idString struct {
+	Id   string
+	Name string
+}
+
+func TestIdKeys(t *testing.T) {
+	uintModel, e := NewBaseModel[idUint](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer uintModel.Pool.Close()
+	stringModel, e := NewBaseModel[idString](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer stringModel.Pool.Close()
+
+	one := int64(1)
+	tests := []struct {
+		name    string
+		idKeys  func(ids any) (any, []any, error)
+		ids     any
+		want    any
+		wantErr bool
+	}{
+		{"uint32", uintModel.idKeys, []uint32{1, 2}, []uint32{1, 2}, false},
+		{"int to uint32", uintModel.idKeys, []int{1, 2}, []uint32{1, 2}, false},
+		{"any", uintModel.idKeys, []any{1, uint64(2), &one}, []uint32{1, 2, 1}, false},
+		{"null int64", uintModel.idKeys, []sql.NullInt64{{Int64: 3, Valid: true}}, []uint32{3}, false},
+		{"integral float", uintModel.idKeys, []float64{4}, []uint32{4}, false},
+		{"negative", uintModel.idKeys, []int{-1}, nil, true},
+		{"overflow", uintModel.idKeys, []int64{1 << 32}, nil, true},
+		{"fraction", uintModel.idKeys, []float64{1.5}, nil, true},
+		{"string to uint32", uintModel.idKeys, []string{"1"}, nil, true},
+		{"null", uintModel.idKeys, []sql.NullInt64{{}}, nil, true},
+		{"nil in any", uintModel.idKeys, []any{nil}, nil, true},
+		{"not a slice", uintModel.idKeys, 1, nil, true},
+		{"string", stringModel.idKeys, []string{"a", "b"}, []string{"a", "b"}, false},
+		{"string in any", stringModel.idKeys, []any{"a"}, []string{"a"}, false},
+		{"int to string", stringModel.idKeys, []int{65}, nil, true},
+		{"uint to string", stringModel.idKeys, []any{uint8(65)}, nil, true},
+	}
+	for _, tt := range tests {
+		keys, list, e := tt.idKeys(tt.ids)
+		if (e != nil) != tt.wantErr {
+			t.Errorf("%s: error %v", tt.name, e)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("%s: keys %#v, want %#v", tt.name, keys, tt.want)
+		}
+		if len(list) != reflect.ValueOf(tt.want).Len() {
+			t.Errorf("%s: %d listed ids, want %d", tt.name, len(list), reflect.ValueOf(tt.want).Len())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package px
+
+import (
+	"log"
+	"reflect"
+	"slices"
+)
+
+// Generated is implemented on *T by the code 'px gen' writes for a model struct T. BaseModel scans into and
+// reads arguments from it instead of reflecting over T, as long as PxColumns matches the model's columns
+type Generated interface {
+	// PxColumns returns the column names, in field order
+	PxColumns() []string
+	// PxColumnPointers returns pointers to the column fields, in PxColumns order
+	PxColumnPointers() []any
+	// PxColumnValues returns the column field values, in PxColumns order
+	PxColumnValues() []any
+}
+
+// detectGenerated reports whether *T has generated code matching the model's columns, logging stale code
+func (b *BaseModel[T]) detectGenerated() bool {
+	g, ok := any(new(T)).(Generated)
+	if !ok {
+		return false
+	}
+	if !slices.Equal(g.PxColumns(), b.dbTags) {
+		log.Println("px: generated code of " + b.Type.String() + " is out of date, run px gen again. Falling back to reflection")
+		return false
+	}
+	return true
+}
+
+// columnValues returns v's column values from generated code, nil without it
+func (b *BaseModel[T]) columnValues(value reflect.Value) []any {
+	if !b.cached().generated {
+		return nil
+	}
+	return value.Addr().Interface().(Generated).PxColumnValues()
+}
+
+// columnValue returns the value of column i, from values if not nil
+func (b *BaseModel[T]) columnValue(values []any, value reflect.Value, i int) any {
+	if values != nil {
+		return values[i]
+	}
+	return b.field(value, i).Interface()
+}
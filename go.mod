@@ -1,6 +1,6 @@
 module github.com/stevenzack/px
 
-go 1.22rc1
+go 1.23
 
 require (
 	github.com/gertd/go-pluralize v0.2.1
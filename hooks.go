@@ -0,0 +1,107 @@
+package px
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Executor runs queries, it's implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx
+type Executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Lifecycle hooks, implemented on T or *T. The executor is the model's active one, so hooks can write in the same transaction.
+// A hook returning an error aborts the operation (and After* errors are returned after the statement has run)
+type (
+	BeforeInsertHook interface {
+		BeforeInsert(ex Executor) error
+	}
+	AfterInsertHook interface {
+		AfterInsert(ex Executor) error
+	}
+	BeforeUpdateHook interface {
+		BeforeUpdate(ex Executor) error
+	}
+	AfterUpdateHook interface {
+		AfterUpdate(ex Executor) error
+	}
+	BeforeDeleteHook interface {
+		BeforeDelete(ex Executor) error
+	}
+	AfterFindHook interface {
+		AfterFind(ex Executor) error
+	}
+)
+
+// WithTx returns a copy of the model that runs every query on tx
+func (b *BaseModel[T]) WithTx(tx Executor) *BaseModel[T] {
+	c := *b
+	c.tx = tx
+	return &c
+}
+
+// Executor returns the active executor, the transaction set by WithTx or the pool
+func (b *BaseModel[T]) Executor() Executor {
+	if b.tx != nil {
+		return b.tx
+	}
+	return b.Pool
+}
+
+func (b *BaseModel[T]) beforeInsert(v *T) error {
+	if h, ok := any(v).(BeforeInsertHook); ok {
+		return h.BeforeInsert(b.Executor())
+	}
+	return nil
+}
+
+func (b *BaseModel[T]) afterInsert(v *T) error {
+	if h, ok := any(v).(AfterInsertHook); ok {
+		return h.AfterInsert(b.Executor())
+	}
+	return nil
+}
+
+func (b *BaseModel[T]) beforeUpdate(v *T) error {
+	if h, ok := any(v).(BeforeUpdateHook); ok {
+		return h.BeforeUpdate(b.Executor())
+	}
+	return nil
+}
+
+func (b *BaseModel[T]) afterUpdate(v *T) error {
+	if h, ok := any(v).(AfterUpdateHook); ok {
+		return h.AfterUpdate(b.Executor())
+	}
+	return nil
+}
+
+func (b *BaseModel[T]) afterFind(v *T) error {
+	if h, ok := any(v).(AfterFindHook); ok {
+		return h.AfterFind(b.Executor())
+	}
+	return nil
+}
+
+// beforeDelete loads the rows matching where (as returned by scope) and calls BeforeDelete on each, if T has the hook
+func (b *BaseModel[T]) beforeDelete(where string, args []any) error {
+	if _, ok := any(new(T)).(BeforeDeleteHook); !ok {
+		return nil
+	}
+	_, query := b.GetSelectSQL()
+	vs, e := b.queryAll(query+where, args)
+	if e != nil {
+		return e
+	}
+	for i := range vs {
+		e = any(&vs[i]).(BeforeDeleteHook).BeforeDelete(b.Executor())
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -3,12 +3,14 @@ package px
 import (
 	"context"
 	"errors"
-	"fmt"
-	"log"
 	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stevenzack/tools/strToolkit"
 )
 
@@ -17,17 +19,42 @@ type (
 		isPrimaryKey bool
 		unique       bool
 		keys         []indexKey
+		name         string   // custom name, see ToIndexName
+		method       string   // btree, hash, gin, gist or brin, btree if empty
+		where        string   // predicate of a partial index
+		include      []string // INCLUDE columns
 	}
 	indexKey struct {
 		lower    bool
 		key      string
 		sequence string
+		ops      string // operator class, like jsonb_path_ops
+	}
+	// indexOptions are the index level options of an index tag
+	indexOptions struct {
+		name    string
+		method  string
+		where   string
+		include []string
 	}
 	IndexSchema struct {
-		SchemaName string `db:"schemaname"`
-		TableName  string `db:"tablename"`
-		IndexName  string `db:"indexname"`
-		IndexDef   string `db:"indexdef"`
+		SchemaName string           `db:"schemaname"`
+		TableName  string           `db:"tablename"`
+		IndexName  string           `db:"indexname"`
+		IndexDef   string           `db:"indexdef"`
+		Unique     bool             `db:"indisunique"`
+		Primary    bool             `db:"indisprimary"`
+		Valid      bool             `db:"indisvalid"`
+		Method     string           `db:"amname"` // btree, hash, gin...
+		Predicate  *string          `db:"predicate"`
+		Keys       []IndexKeySchema // key columns in index order, INCLUDE columns excluded
+		Include    []string
+	}
+	IndexKeySchema struct {
+		Column     string // column name, empty for an expression
+		Expression string // like lower(email), empty for a column
+		Desc       bool
+		OpClass    string // operator class, empty for the column type's default one
 	}
 )
 
@@ -37,7 +64,11 @@ func (a sortByIndexKey) Len() int           { return len(a) }
 func (a sortByIndexKey) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a sortByIndexKey) Less(i, j int) bool { return a[i].key < a[j].key }
 
+// ToIndexName returns the custom name of the index tag, or table_column_idx
 func (i *indexModel) ToIndexName(tableName string) string {
+	if i.name != "" {
+		return i.name
+	}
 	buf := new(strings.Builder)
 	buf.WriteString(tableName + "_")
 	for _, k := range i.keys {
@@ -60,6 +91,21 @@ func (i *indexModel) JoinKeys(sep string) string {
 	}
 	return builder.String()
 }
+
+// toIndexModels parses index tags, map[column_name]"single=asc,unique=true,lower=true,group=unique". Options:
+//
+//	single[=desc]      the column's own index, when it's also in a group
+//	unique             unique index, or a group whose name starts with 'unique'
+//	group=name         multi column index of the columns sharing the group name, 'pkey' for the primary key
+//	lower=true         index lower(column)
+//	desc=true          descending key, of the group if any
+//	ops=opclass        operator class of the key, like jsonb_path_ops
+//	method=gin         index method: btree, hash, gin, gist or brin
+//	where=predicate    partial index, with %2C for commas
+//	include=a b        INCLUDE columns, separated by spaces
+//	name=custom_idx    index name instead of table_column_idx
+//
+// method, where, include and name apply to the single index if any, else to the group
 func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error) {
 	imodels := []indexModel{}
 	groupMap := make(map[string]indexModel)
@@ -71,7 +117,10 @@ func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error)
 
 		imodel := indexModel{}
 		lower := false
+		desc := false
+		ops := ""
 		group := ""
+		options := indexOptions{}
 		for k := range vs {
 			v := vs.Get(k)
 			switch k {
@@ -93,20 +142,47 @@ func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error)
 				group = vs.Get(k)
 			case "lower":
 				lower = v == "true"
+			case "desc":
+				desc = v == "true"
+			case "ops":
+				ops = v
+			case "method":
+				switch v {
+				case "btree", "hash", "gin", "gist", "brin":
+				default:
+					return nil, nil, errors.New("field '" + key + "', unsupported index method:" + v)
+				}
+				options.method = v
+			case "where":
+				options.where = v
+			case "include":
+				options.include = strings.Fields(v)
+			case "name":
+				options.name = v
 			default:
 				return nil, nil, errors.New("field '" + key + "', unsupported key:" + k)
 			}
 		}
+		sequence := "asc"
+		if desc {
+			sequence = "desc"
+		}
 
 		// normal index
 		if group == "" {
 			if len(imodel.keys) == 0 {
 				imodel.keys = append(imodel.keys, indexKey{
 					key:      key,
-					sequence: "asc",
+					sequence: sequence,
 					lower:    lower,
 				})
 			}
+			if desc {
+				imodel.keys[0].sequence = "desc"
+			}
+			imodel.keys[0].lower = lower
+			imodel.keys[0].ops = ops
+			options.apply(&imodel)
 			imodels = append(imodels, imodel)
 			continue
 		}
@@ -114,7 +190,10 @@ func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error)
 		//another single index
 		if len(imodel.keys) > 0 {
 			imodel.keys[0].lower = lower
+			imodel.keys[0].ops = ops
+			options.apply(&imodel)
 			imodels = append(imodels, imodel)
+			options = indexOptions{}
 		}
 
 		//group index
@@ -128,23 +207,26 @@ func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error)
 			}
 			before.keys = append(before.keys, indexKey{
 				key:      strToolkit.SubBefore(key, ",", key),
-				sequence: "asc",
+				sequence: sequence,
 				lower:    lower,
+				ops:      ops,
 			})
 			if strings.HasPrefix(group, "unique") {
 				before.unique = true
 			}
-
-			groupMap[group] = before
-			continue
+		} else {
+			//append
+			before.keys = append(before.keys, indexKey{
+				key:      key,
+				sequence: sequence,
+				lower:    lower,
+				ops:      ops,
+			})
+		}
+		e = options.merge(&before)
+		if e != nil {
+			return nil, nil, errors.New("field '" + key + "', group " + group + ": " + e.Error())
 		}
-
-		//append
-		before.keys = append(before.keys, indexKey{
-			key:      key,
-			sequence: "asc",
-			lower:    lower,
-		})
 		groupMap[group] = before
 	}
 
@@ -161,60 +243,90 @@ func toIndexModels(indexes map[string]string) (*indexModel, []indexModel, error)
 	return primaryKeyModel, imodels, nil
 }
 
-// createIndexFromField create index with format like: map[column_name]"single=asc,unique=true,lower=true,group=unique"
-func (b *BaseModel[T]) createIndexFromField(imodels []indexModel) error {
-	if len(imodels) == 0 {
-		return nil
-	}
+func (o indexOptions) apply(imodel *indexModel) {
+	imodel.name, imodel.method, imodel.where, imodel.include = o.name, o.method, o.where, o.include
+}
 
-	for _, imodel := range imodels {
-		e := b.createIndex(imodel)
-		if e != nil {
-			log.Println(e)
-			return e
+// merge sets the options of a group from one of its columns' tags, which must not contradict the others
+func (o indexOptions) merge(imodel *indexModel) error {
+	for _, option := range []struct {
+		name, value string
+		dst         *string
+	}{
+		{"name", o.name, &imodel.name},
+		{"method", o.method, &imodel.method},
+		{"where", o.where, &imodel.where},
+	} {
+		if option.value == "" {
+			continue
+		}
+		if *option.dst != "" && *option.dst != option.value {
+			return errors.New("conflicting " + option.name + " options")
 		}
+		*option.dst = option.value
 	}
+	imodel.include = append(imodel.include, o.include...)
 	return nil
 }
 
-func (b *BaseModel[T]) createIndex(imodel indexModel) error {
+// createIndexSQL returns the create index statement of imodel, named ToIndexName
+func (b *BaseModel[T]) createIndexSQL(imodel indexModel, concurrently bool) string {
 	builder := new(strings.Builder)
 	builder.WriteString("create ")
 	if imodel.unique {
 		builder.WriteString("unique ")
 	}
-	builder.WriteString("index on " + b.Schema + "." + b.TableName + " (")
+	builder.WriteString("index ")
+	if concurrently {
+		builder.WriteString("concurrently ")
+	}
+	builder.WriteString(imodel.ToIndexName(b.TableName) + " on " + b.Schema + "." + b.TableName)
+	if imodel.method != "" {
+		builder.WriteString(" using " + imodel.method)
+	}
+	builder.WriteString(" (")
 	for i, key := range imodel.keys {
 		if key.lower {
 			builder.WriteString("lower(" + key.key + ")")
 		} else {
 			builder.WriteString(key.key)
 		}
-		builder.WriteString(" asc")
+		if key.ops != "" {
+			builder.WriteString(" " + key.ops)
+		}
+		if imodel.method == "" || imodel.method == "btree" {
+			builder.WriteString(" " + key.sequence)
+		}
 		if i < len(imodel.keys)-1 {
 			builder.WriteString(",")
 		}
 	}
 	builder.WriteString(")")
-	query := builder.String()
-	_, e := b.Pool.Exec(context.Background(), query)
-	if e != nil {
-		return fmt.Errorf("%w:%s", e, query)
+	if len(imodel.include) > 0 {
+		builder.WriteString(" include (" + strings.Join(imodel.include, ",") + ")")
 	}
-	return nil
-}
-
-func (b *BaseModel[T]) dropIndex(name string) error {
-	query := `drop index ` + name
-	_, e := b.Pool.Exec(context.Background(), query)
-	if e != nil {
-		return fmt.Errorf("%w:%s", e, query)
+	if imodel.where != "" {
+		builder.WriteString(" where " + imodel.where)
 	}
-	return nil
+	return builder.String()
 }
 
 func (b *BaseModel[T]) GetIndexes() ([]IndexSchema, error) {
-	rows, e := b.Pool.Query(context.Background(), `select schemaname,tablename,indexname,indexdef from pg_indexes where tablename=$1`, b.TableName)
+	return DescIndexes(b.Pool, b.Schema, b.TableName)
+}
+
+var predicateCastRegexp = regexp.MustCompile(`::(character varying|timestamp with(out)? time zone|double precision|[a-z_0-9]+)(\[\])?`)
+
+// DescIndexes lists the indexes of a table, its primary key index included
+func DescIndexes(pool *pgxpool.Pool, schema, tableName string) ([]IndexSchema, error) {
+	rows, e := pool.Query(context.Background(), `select n.nspname,t.relname,i.relname,pg_get_indexdef(i.oid),x.indisunique,x.indisprimary,x.indisvalid,am.amname,pg_get_expr(x.indpred,x.indrelid),
+		array(select pg_get_indexdef(i.oid,k,true) from generate_series(1,x.indnkeyatts) k order by k),
+		array(select x.indkey[k-1]=0 from generate_series(1,x.indnkeyatts) k order by k),
+		array(select x.indoption[k-1]&1=1 from generate_series(1,x.indnkeyatts) k order by k),
+		array(select case when c.opcdefault then '' else c.opcname end from generate_series(1,x.indnkeyatts) k join pg_opclass c on c.oid=x.indclass[k-1] order by k),
+		array(select pg_get_indexdef(i.oid,k,true) from generate_series(x.indnkeyatts+1,x.indnatts) k order by k)
+		from pg_index x join pg_class i on i.oid=x.indexrelid join pg_class t on t.oid=x.indrelid join pg_namespace n on n.oid=t.relnamespace join pg_am am on am.oid=i.relam
+		where n.nspname=$1 and t.relname=$2 order by i.relname`, schema, tableName)
 	if e != nil {
 		return nil, e
 	}
@@ -222,7 +334,54 @@ func (b *BaseModel[T]) GetIndexes() ([]IndexSchema, error) {
 	vs := []IndexSchema{}
 	for rows.Next() {
 		v := IndexSchema{}
-		e = rows.Scan(&v.SchemaName, &v.TableName, &v.IndexName, &v.IndexDef)
+		var keys, opClasses []string
+		var expressions, descs []bool
+		e = rows.Scan(&v.SchemaName, &v.TableName, &v.IndexName, &v.IndexDef, &v.Unique, &v.Primary, &v.Valid, &v.Method, &v.Predicate, &keys, &expressions, &descs, &opClasses, &v.Include)
+		if e != nil {
+			break
+		}
+		for k, key := range keys {
+			if expressions[k] {
+				v.Keys = append(v.Keys, IndexKeySchema{Expression: key, Desc: descs[k], OpClass: opClasses[k]})
+			} else {
+				v.Keys = append(v.Keys, IndexKeySchema{Column: key, Desc: descs[k], OpClass: opClasses[k]})
+			}
+		}
+		vs = append(vs, v)
+	}
+	//check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, e
+	}
+
+	return vs, nil
+}
+
+// IndexProgress is a row of pg_stat_progress_create_index, an index being built
+type IndexProgress struct {
+	IndexName   string // empty until the build has created the index
+	Command     string // CREATE INDEX, CREATE INDEX CONCURRENTLY, REINDEX ...
+	Phase       string
+	BlocksDone  int64
+	BlocksTotal int64
+	TuplesDone  int64
+	TuplesTotal int64
+}
+
+// DescIndexProgress returns the index builds in progress on a table
+func DescIndexProgress(pool *pgxpool.Pool, schema, tableName string) ([]IndexProgress, error) {
+	rows, e := pool.Query(context.Background(), `select coalesce(i.relname,''),p.command,p.phase,p.blocks_done,p.blocks_total,p.tuples_done,p.tuples_total
+		from pg_stat_progress_create_index p join pg_class t on t.oid=p.relid join pg_namespace n on n.oid=t.relnamespace left join pg_class i on i.oid=p.index_relid
+		where n.nspname=$1 and t.relname=$2`, schema, tableName)
+	if e != nil {
+		return nil, e
+	}
+
+	vs := []IndexProgress{}
+	for rows.Next() {
+		v := IndexProgress{}
+		e = rows.Scan(&v.IndexName, &v.Command, &v.Phase, &v.BlocksDone, &v.BlocksTotal, &v.TuplesDone, &v.TuplesTotal)
 		if e != nil {
 			break
 		}
@@ -236,3 +395,172 @@ func (b *BaseModel[T]) GetIndexes() ([]IndexSchema, error) {
 
 	return vs, nil
 }
+
+// String formats the build like 'users_email_idx: building index: 1200/5000 blocks, 0/0 tuples'
+func (p IndexProgress) String() string {
+	return p.IndexName + ": " + p.Phase + ": " + strconv.FormatInt(p.BlocksDone, 10) + "/" + strconv.FormatInt(p.BlocksTotal, 10) + " blocks, " +
+		strconv.FormatInt(p.TuplesDone, 10) + "/" + strconv.FormatInt(p.TuplesTotal, 10) + " tuples"
+}
+
+// matches reports whether the remote index has imodel's definition: uniqueness, method, keys, lower(), order,
+// operator classes, INCLUDE columns and predicate
+func (imodel *indexModel) matches(remote IndexSchema) bool {
+	method := imodel.method
+	if method == "" {
+		method = "btree"
+	}
+	if imodel.unique != remote.Unique || method != remote.Method || len(imodel.keys) != len(remote.Keys) {
+		return false
+	}
+	predicate := ""
+	if remote.Predicate != nil {
+		predicate = *remote.Predicate
+	}
+	if normalizePredicate(imodel.where) != normalizePredicate(predicate) || strings.Join(imodel.include, ",") != strings.Join(remote.Include, ",") {
+		return false
+	}
+	for i, key := range imodel.keys {
+		r := remote.Keys[i]
+		if method == "btree" && (key.sequence == "desc") != r.Desc {
+			return false
+		}
+		if key.ops != r.OpClass {
+			return false
+		}
+		if key.lower {
+			if normalizeExpression(r.Expression) != "lower("+key.key+")" {
+				return false
+			}
+			continue
+		}
+		if r.Column != key.key {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeExpression removes the text casts and extra parentheses postgres adds, lower((email)::text) becomes lower(email)
+func normalizeExpression(expr string) string {
+	expr = strings.ReplaceAll(expr, "::text", "")
+	for strings.Contains(expr, "((") {
+		expr = strings.ReplaceAll(strings.ReplaceAll(expr, "((", "("), "))", ")")
+	}
+	return expr
+}
+
+// normalizePredicate makes a local where= option comparable with the predicate postgres prints: lower case,
+// without casts, parentheses and spaces
+func normalizePredicate(predicate string) string {
+	predicate = predicateCastRegexp.ReplaceAllString(strings.ToLower(predicate), "")
+	return strings.Map(func(r rune) rune {
+		if r == '(' || r == ')' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, predicate)
+}
+
+// renameColumns returns the remote index as it is after the columns are renamed, renames mapping former names to new ones
+func (remote IndexSchema) renameColumns(renames map[string]string) IndexSchema {
+	if len(renames) == 0 {
+		return remote
+	}
+	keys := make([]IndexKeySchema, len(remote.Keys))
+	for i, key := range remote.Keys {
+		if to, ok := renames[key.Column]; ok {
+			key.Column = to
+		}
+		key.Expression = renameIdentifiers(key.Expression, renames)
+		keys[i] = key
+	}
+	remote.Keys = keys
+	include := make([]string, len(remote.Include))
+	for i, column := range remote.Include {
+		if to, ok := renames[column]; ok {
+			column = to
+		}
+		include[i] = column
+	}
+	remote.Include = include
+	if remote.Predicate != nil {
+		predicate := renameIdentifiers(*remote.Predicate, renames)
+		remote.Predicate = &predicate
+	}
+	return remote
+}
+
+// renameIdentifiers renames the column identifiers of a SQL expression, leaving string literals, function names
+// (identifiers followed by '(') and cast types alone
+func renameIdentifiers(expr string, renames map[string]string) string {
+	builder := new(strings.Builder)
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '\'':
+			// string literal, '' being an escaped quote
+			end := i + 1
+			for end < len(expr) {
+				if expr[end] == '\'' && (end+1 == len(expr) || expr[end+1] != '\'') {
+					end++
+					break
+				}
+				if expr[end] == '\'' {
+					end++
+				}
+				end++
+			}
+			builder.WriteString(expr[i:end])
+			i = end
+		case c == '"':
+			// quoted identifier
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end == -1 {
+				builder.WriteString(expr[i:])
+				return builder.String()
+			}
+			name := expr[i+1 : i+1+end]
+			if to, ok := renames[name]; ok {
+				name = to
+			}
+			builder.WriteString(`"` + name + `"`)
+			i += end + 2
+		case isNameStart(c) || isDigit(c):
+			end := i + 1
+			for end < len(expr) && (isNameStart(expr[end]) || isDigit(expr[end])) {
+				end++
+			}
+			name := expr[i:end]
+			next := end
+			for next < len(expr) && expr[next] == ' ' {
+				next++
+			}
+			function := next < len(expr) && expr[next] == '('
+			cast := strings.HasSuffix(expr[:i], "::")
+			if to, ok := renames[name]; ok && isNameStart(c) && !function && !cast {
+				name = to
+			}
+			builder.WriteString(name)
+			i = end
+		default:
+			builder.WriteByte(c)
+			i++
+		}
+	}
+	return builder.String()
+}
+
+// formerName returns the index name before the renames of its columns, renames mapping former names to new ones
+func (i *indexModel) formerName(tableName string, renames map[string]string) string {
+	former := *i
+	former.keys = make([]indexKey, len(i.keys))
+	for k, key := range i.keys {
+		for from, to := range renames {
+			if key.key == to {
+				key.key = from
+			}
+		}
+		former.keys[k] = key
+	}
+	return former.ToIndexName(tableName)
+}
@@ -0,0 +1,202 @@
+package px
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeExpression(t *testing.T) {
+	tests := []struct{ expr, want string }{
+		{"lower((email)::text)", "lower(email)"},
+		{"lower(email)", "lower(email)"},
+		{"lower(((name)::text))", "lower(name)"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeExpression(tt.expr); got != tt.want {
+			t.Errorf("normalizeExpression(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestIndexMatches(t *testing.T) {
+	email := indexModel{unique: true, keys: []indexKey{{key: "email", lower: true, sequence: "asc"}}}
+	group := indexModel{keys: []indexKey{{key: "a", sequence: "asc"}, {key: "b", sequence: "asc"}}}
+	tests := []struct {
+		name   string
+		local  indexModel
+		remote IndexSchema
+		want   bool
+	}{
+		{"lower", email, IndexSchema{Unique: true, Method: "btree", Keys: []IndexKeySchema{{Expression: "lower((email)::text)"}}}, true},
+		{"not unique", email, IndexSchema{Method: "btree", Keys: []IndexKeySchema{{Expression: "lower((email)::text)"}}}, false},
+		{"not lower", email, IndexSchema{Unique: true, Method: "btree", Keys: []IndexKeySchema{{Column: "email"}}}, false},
+		{"group", group, IndexSchema{Method: "btree", Keys: []IndexKeySchema{{Column: "a"}, {Column: "b"}}}, true},
+		{"key order", group, IndexSchema{Method: "btree", Keys: []IndexKeySchema{{Column: "b"}, {Column: "a"}}}, false},
+		{"fewer keys", group, IndexSchema{Method: "btree", Keys: []IndexKeySchema{{Column: "a"}}}, false},
+		{"method", group, IndexSchema{Method: "hash", Keys: []IndexKeySchema{{Column: "a"}, {Column: "b"}}}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.local.matches(tt.remote); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToIndexModels(t *testing.T) {
+	b := &BaseModel[struct{}]{Schema: "public", TableName: "posts"}
+	tests := []struct {
+		name    string
+		tags    map[string]string
+		want    []string // create index statements, in any order
+		pkey    []string
+		wantErr bool
+	}{
+		{
+			name: "single",
+			tags: map[string]string{"title": "", "email": "unique,lower=true"},
+			want: []string{
+				"create index posts_title_idx on public.posts (title asc)",
+				"create unique index posts_lower_idx on public.posts (lower(email) asc)",
+			},
+		},
+		{
+			name: "desc",
+			tags: map[string]string{"score": "single=desc", "rank": "desc=true", "hits": "single,desc=true"},
+			want: []string{
+				"create index posts_score_idx on public.posts (score desc)",
+				"create index posts_rank_idx on public.posts (rank desc)",
+				"create index posts_hits_idx on public.posts (hits desc)",
+			},
+		},
+		{
+			name: "group with single",
+			tags: map[string]string{"user_id": "group=user_time", "created_at": "single,group=user_time,desc=true,name=recent_idx"},
+			want: []string{
+				"create index recent_idx on public.posts (created_at asc)",
+				"create index posts_created_at_user_id_idx on public.posts (created_at desc,user_id asc)",
+			},
+		},
+		{
+			name: "options",
+			tags: map[string]string{
+				"tags":   "method=gin,ops=jsonb_path_ops",
+				"status": "where=status in ('a'%2C'b'),include=title user_id",
+			},
+			want: []string{
+				"create index posts_tags_idx on public.posts using gin (tags jsonb_path_ops)",
+				"create index posts_status_idx on public.posts (status asc) include (title,user_id) where status in ('a','b')",
+			},
+		},
+		{
+			name: "group options",
+			tags: map[string]string{"a": "group=uniq_ab,where=a>0", "b": "group=uniq_ab,method=btree"},
+			want: []string{"create index posts_a_b_idx on public.posts using btree (a asc,b asc) where a>0"},
+		},
+		{
+			name: "primary key",
+			tags: map[string]string{"tenant_id": "group=pkey"},
+			pkey: []string{"id", "tenant_id"},
+		},
+		{name: "unsupported method", tags: map[string]string{"a": "method=rtree"}, wantErr: true},
+		{name: "unsupported option", tags: map[string]string{"a": "sparse=true"}, wantErr: true},
+		{name: "conflicting group options", tags: map[string]string{"a": "group=g,method=gin", "b": "group=g,method=gist"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		pkey, imodels, e := toIndexModels(tt.tags)
+		if (e != nil) != tt.wantErr {
+			t.Errorf("%s: error %v", tt.name, e)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		got := map[string]bool{}
+		for _, imodel := range imodels {
+			got[b.createIndexSQL(imodel, false)] = true
+		}
+		for _, want := range tt.want {
+			if !got[want] {
+				t.Errorf("%s: missing %q in %v", tt.name, want, got)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d indexes, want %d", tt.name, len(got), len(tt.want))
+		}
+		if (pkey == nil) != (tt.pkey == nil) || (pkey != nil && pkey.JoinKeys(",") != strings.Join(tt.pkey, ",")) {
+			t.Errorf("%s: primary key %+v, want %v", tt.name, pkey, tt.pkey)
+		}
+	}
+}
+
+func TestNormalizePredicate(t *testing.T) {
+	tests := []struct{ local, remote string }{
+		{"deleted_at is null", "(deleted_at IS NULL)"},
+		// postgres rewrites in () into = any(array[]), only casts, case, parentheses and spaces are normalized
+		{"status = any(array['a','b'])", "((status)::text = ANY ((ARRAY['a'::character varying, 'b'::character varying])::text[]))"},
+		{"a > 0", "(a > 0)"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if normalizePredicate(tt.local) != normalizePredicate(tt.remote) {
+			t.Errorf("normalizePredicate(%q) = %q, normalizePredicate(%q) = %q", tt.local, normalizePredicate(tt.local), tt.remote, normalizePredicate(tt.remote))
+		}
+	}
+}
+
+func TestRenameIdentifiers(t *testing.T) {
+	renames := map[string]string{"mail": "email", "lower": "lowered", "text": "body"}
+	tests := []struct{ expr, want string }{
+		{"lower((mail)::text)", "lower((email)::text)"},
+		{"(mail IS NOT NULL)", "(email IS NOT NULL)"},
+		{"(status = 'mail')", "(status = 'mail')"},
+		{"(note = 'it''s mail' AND mail <> '')", "(note = 'it''s mail' AND email <> '')"},
+		{"(lower IS NULL)", "(lowered IS NULL)"},
+		{"lower (mail)", "lower (email)"},
+		{`("mail" > 1)`, `("email" > 1)`},
+		{"(text)::text", "(body)::text"},
+		{"(mail1 = 1e5)", "(mail1 = 1e5)"},
+	}
+	for _, tt := range tests {
+		if got := renameIdentifiers(tt.expr, renames); got != tt.want {
+			t.Errorf("renameIdentifiers(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestRenameColumns(t *testing.T) {
+	predicate := "(mail <> 'mail')"
+	remote := IndexSchema{
+		IndexName: "users_lower_idx",
+		Keys:      []IndexKeySchema{{Expression: "lower((mail)::text)"}, {Column: "mail"}, {Column: "name"}},
+		Include:   []string{"mail"},
+		Predicate: &predicate,
+	}
+	got := remote.renameColumns(map[string]string{"mail": "email"})
+	if got.Keys[0].Expression != "lower((email)::text)" || got.Keys[1].Column != "email" || got.Keys[2].Column != "name" {
+		t.Errorf("keys = %+v", got.Keys)
+	}
+	if got.Include[0] != "email" || *got.Predicate != "(email <> 'mail')" {
+		t.Errorf("include %v, predicate %q", got.Include, *got.Predicate)
+	}
+	if remote.Keys[1].Column != "mail" || remote.Include[0] != "mail" || *remote.Predicate != predicate {
+		t.Error("renameColumns changed the remote index")
+	}
+}
+
+func TestFormerName(t *testing.T) {
+	renames := map[string]string{"mail": "email"}
+	tests := []struct {
+		imodel indexModel
+		want   string
+	}{
+		{indexModel{keys: []indexKey{{key: "email"}, {key: "name"}}}, "users_mail_name_idx"},
+		{indexModel{keys: []indexKey{{key: "name"}}}, "users_name_idx"},
+		{indexModel{name: "custom_idx", keys: []indexKey{{key: "email"}}}, "custom_idx"},
+	}
+	for _, tt := range tests {
+		if got := tt.imodel.formerName("users", renames); got != tt.want {
+			t.Errorf("formerName(%+v) = %q, want %q", tt.imodel.keys, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Table is a model's table that queries can join, implemented by every *BaseModel
+type Table interface {
+	table() *tableMeta
+}
+
+type tableMeta struct {
+	name       string
+	modelType  reflect.Type
+	dbTags     []string
+	fields     []int
+	primaryKey string
+	softDelete string            // 'table.deleted_at is null', empty if none
+	joinOn     map[string]string // join conditions to the tables of declared relations, by table name
+}
+
+type queryJoin struct {
+	kind  string // join, left join
+	table *tableMeta
+	on    string
+}
+
+func (b *BaseModel[T]) table() *tableMeta {
+	t := &tableMeta{
+		name:       b.TableName,
+		modelType:  b.Type,
+		dbTags:     b.dbTags,
+		fields:     b.fields,
+		primaryKey: b.dbTags[b.primaryKeys[0]],
+		joinOn:     make(map[string]string),
+	}
+	if b.softDelete != -1 && !b.unscoped {
+		t.softDelete = b.TableName + "." + b.dbTags[b.softDelete] + " is null"
+	}
+	for _, r := range b.relations {
+		if table, on := r.joinOn(); table != "" {
+			t.joinOn[table] = on
+		}
+	}
+	return t
+}
+
+func (r *belongsTo[T, R]) joinOn() (string, string) {
+	return r.target.TableName, r.parent.TableName + "." + r.parent.dbTags[r.fk] + "=" + r.target.TableName + "." + r.target.dbTags[r.target.primaryKeys[0]]
+}
+
+func (r *hasMany[T, R]) joinOn() (string, string) {
+	return r.target.TableName, r.target.TableName + "." + r.target.dbTags[r.fk] + "=" + r.parent.TableName + "." + r.parent.dbTags[r.parent.primaryKeys[0]]
+}
+
+func (r *manyToMany[T, R]) joinOn() (string, string) {
+	return "", ""
+}
+
+// Join inner joins t on the condition, or on the relation declared between the two models if on is empty.
+// Columns of t can be used as 'table.column' in conditions and orders
+func (q *Query[T]) Join(t Table, on string) *Query[T] {
+	q.joins = append(q.joins, queryJoin{kind: "join", table: t.table(), on: on})
+	return q
+}
+
+// LeftJoin is Join with a left join, use a pointer field in JoinAll's result type to receive nil for missing rows
+func (q *Query[T]) LeftJoin(t Table, on string) *Query[T] {
+	q.joins = append(q.joins, queryJoin{kind: "left join", table: t.table(), on: on})
+	return q
+}
+
+// from returns ' from table join ...'
+func (q *Query[T]) from() (string, error) {
+	builder := new(strings.Builder)
+	builder.WriteString(" from " + q.model.TableName)
+	base := q.model.table()
+	for _, join := range q.joins {
+		on := join.on
+		if on == "" {
+			on = base.joinOn[join.table.name]
+		}
+		if on == "" {
+			on = join.table.joinOn[base.name]
+		}
+		if on == "" {
+			return "", errors.New("no relation declared between " + base.name + " and " + join.table.name + ", give a join condition")
+		}
+		if join.table.softDelete != "" {
+			on = "(" + on + ") and " + join.table.softDelete
+		}
+		builder.WriteString(" " + join.kind + " " + join.table.name + " on " + on)
+	}
+	return builder.String(), nil
+}
+
+// joinedColumn validates a table.column of a joined table
+func (q *Query[T]) joinedColumn(table, column string) bool {
+	for _, join := range q.joins {
+		if join.table.name == table {
+			for _, dbTag := range join.table.dbTags {
+				if dbTag == column {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// JoinAll runs a query with joins, scanning each row into R, whose fields of the models' types (or pointers to them,
+// for left joins) receive the columns of each table:
+//
+//	type OrderWithUser struct {
+//		Order
+//		User *User
+//	}
+//	vs, e := px.JoinAll[OrderWithUser](orders.Select().LeftJoin(users, "").Where(px.Eq("users.country", "NZ")))
+//
+// AfterFind runs on each scanned model
+func JoinAll[R any, T any](q *Query[T]) ([]R, error) {
+	return joinQuery[R](q, false)
+}
+
+// JoinOne is JoinAll returning the first row, or ErrNotFound
+func JoinOne[R any, T any](q *Query[T]) (*R, error) {
+	vs, e := joinQuery[R](q, true)
+	if e != nil {
+		return nil, e
+	}
+	if len(vs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &vs[0], nil
+}
+
+type joinTarget struct {
+	table *tableMeta
+	field int  // field index in R
+	ptr   bool // R's field is a pointer, scanned columns may all be null
+}
+
+func joinQuery[R any, T any](q *Query[T], one bool) ([]R, error) {
+	var r R
+	rt := reflect.TypeOf(r)
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, errors.New("join result type must be struct type")
+	}
+
+	//targets
+	tables := []*tableMeta{q.model.table()}
+	for _, join := range q.joins {
+		tables = append(tables, join.table)
+	}
+	targets := []joinTarget{}
+	selection := []string{}
+	for _, table := range tables {
+		target := joinTarget{table: table, field: -1}
+		for i := 0; i < rt.NumField(); i++ {
+			ft := rt.Field(i).Type
+			if ft == table.modelType {
+				target.field = i
+				break
+			}
+			if ft.Kind() == reflect.Ptr && ft.Elem() == table.modelType {
+				target.field = i
+				target.ptr = true
+				break
+			}
+		}
+		if target.field == -1 {
+			return nil, errors.New(rt.String() + " has no field of type " + table.modelType.String() + " for table " + table.name)
+		}
+		targets = append(targets, target)
+		for _, dbTag := range table.dbTags {
+			selection = append(selection, table.name+"."+dbTag)
+		}
+	}
+
+	//query
+	from, e := q.from()
+	if e != nil {
+		return nil, e
+	}
+	where, args, e := q.where(nil)
+	if e != nil {
+		return nil, e
+	}
+	limit := q.limit
+	if one {
+		q.limit = 1
+	}
+	trail, e := q.trail()
+	q.limit = limit
+	if e != nil {
+		return nil, e
+	}
+	query := `select ` + strings.Join(selection, ",") + from + where + trail
+
+	rows, e := q.model.Executor().Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, q.model.toError(e, query)
+	}
+
+	vs := []R{}
+	for rows.Next() {
+		var v R
+		value := reflect.ValueOf(&v).Elem()
+		fieldArgs := []any{}
+		nullables := [][]reflect.Value{}
+		for _, target := range targets {
+			if !target.ptr {
+				tv := value.Field(target.field)
+				for _, i := range target.table.fields {
+					fieldArgs = append(fieldArgs, tv.Field(i).Addr().Interface())
+				}
+				nullables = append(nullables, nil)
+				continue
+			}
+			// scan into **field so a missing left joined row leaves nils
+			ptrs := []reflect.Value{}
+			for _, i := range target.table.fields {
+				p := reflect.New(reflect.PointerTo(target.table.modelType.Field(i).Type))
+				ptrs = append(ptrs, p)
+				fieldArgs = append(fieldArgs, p.Interface())
+			}
+			nullables = append(nullables, ptrs)
+		}
+		e = rows.Scan(fieldArgs...)
+		if e != nil {
+			break
+		}
+
+		for n, target := range targets {
+			if !target.ptr {
+				continue
+			}
+			ptrs := nullables[n]
+			if ptrs[0].Elem().IsNil() {
+				// the id column is null: no joined row
+				continue
+			}
+			tv := reflect.New(target.table.modelType)
+			for j, i := range target.table.fields {
+				if !ptrs[j].Elem().IsNil() {
+					tv.Elem().Field(i).Set(ptrs[j].Elem().Elem())
+				}
+			}
+			value.Field(target.field).Set(tv)
+		}
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, q.model.toError(e, query)
+	}
+
+	//hooks, once the rows are closed
+	for n := range vs {
+		value := reflect.ValueOf(&vs[n]).Elem()
+		for _, target := range targets {
+			model := value.Field(target.field)
+			if target.ptr {
+				if model.IsNil() {
+					continue
+				}
+			} else {
+				model = model.Addr()
+			}
+			if h, ok := model.Interface().(AfterFindHook); ok {
+				e = h.AfterFind(q.model.Executor())
+				if e != nil {
+					return nil, e
+				}
+			}
+		}
+	}
+	return vs, nil
+}
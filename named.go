@@ -0,0 +1,153 @@
+package px
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+var positional = regexp.MustCompile(`\$[0-9]`)
+
+// bindNamed rewrites ':name' parameters in texts into $n positional parameters, when args is a single map or struct
+// providing the values. Struct fields are matched by their snake_case column name or their Go name.
+// Texts share the numbering, so sets and where can be bound together. Otherwise texts and args are returned unchanged
+func bindNamed(args []any, texts ...string) ([]string, []any, error) {
+	if len(args) != 1 || !isNamedSource(args[0]) {
+		return texts, args, nil
+	}
+	named := false
+	for _, text := range texts {
+		if len(namedParams(text)) > 0 {
+			named = true
+			break
+		}
+	}
+	if !named {
+		return texts, args, nil
+	}
+
+	for _, text := range texts {
+		if positional.MatchString(text) {
+			return nil, nil, errors.New("can't mix named and positional parameters: " + text)
+		}
+	}
+
+	source := reflect.ValueOf(args[0])
+	if source.Kind() == reflect.Ptr {
+		source = source.Elem()
+	}
+	indexes := make(map[string]int)
+	out := []any{}
+	for n, text := range texts {
+		builder := new(strings.Builder)
+		last := 0
+		for _, param := range namedParams(text) {
+			index, ok := indexes[param.name]
+			if !ok {
+				v, e := namedValue(source, param.name)
+				if e != nil {
+					return nil, nil, e
+				}
+				out = append(out, v)
+				index = len(out)
+				indexes[param.name] = index
+			}
+			builder.WriteString(text[last:param.start])
+			builder.WriteString("$" + strconv.Itoa(index))
+			last = param.end
+		}
+		builder.WriteString(text[last:])
+		texts[n] = builder.String()
+	}
+	return texts, out, nil
+}
+
+func isNamedSource(v any) bool {
+	if v == nil {
+		return false
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		if reflect.ValueOf(v).IsNil() {
+			return false
+		}
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String
+	case reflect.Struct:
+		return t.String() != "time.Time"
+	}
+	return false
+}
+
+func namedValue(source reflect.Value, name string) (any, error) {
+	if source.Kind() == reflect.Map {
+		v := source.MapIndex(reflect.ValueOf(name).Convert(source.Type().Key()))
+		if !v.IsValid() {
+			return nil, errors.New("missing named parameter :" + name)
+		}
+		return v.Interface(), nil
+	}
+	for i := 0; i < source.NumField(); i++ {
+		field := source.Type().Field(i)
+		if field.IsExported() && (strcase.ToSnake(field.Name) == name || field.Name == name) {
+			return source.Field(i).Interface(), nil
+		}
+	}
+	return nil, errors.New("missing named parameter :" + name + " in " + source.Type().String())
+}
+
+type namedParam struct {
+	name       string
+	start, end int
+}
+
+// namedParams finds ':name' parameters, skipping quoted strings, quoted identifiers, '::' casts and array slices
+// like arr[a:b], whose ':' follows an identifier character or '['
+func namedParams(text string) []namedParam {
+	out := []namedParam{}
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':' && i+1 < len(text) && text[i+1] == ':':
+			i++
+		case c == ':' && i > 0 && (isNameStart(text[i-1]) || isDigit(text[i-1]) || text[i-1] == '['):
+			// array slice
+		case c == ':' && i+1 < len(text) && isNameStart(text[i+1]):
+			end := i + 1
+			for end < len(text) && (isNameStart(text[end]) || isDigit(text[end])) {
+				end++
+			}
+			out = append(out, namedParam{name: text[i+1 : end], start: i, end: end})
+			i = end - 1
+		}
+	}
+	return out
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
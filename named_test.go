@@ -0,0 +1,105 @@
+package px
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamedParams(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"where id=:id", []string{"id"}},
+		{"where a=:a and b in (:b_1, :a)", []string{"a", "b_1", "a"}},
+		{"where note=':skipped' and x=:x", []string{"x"}},
+		{`where "odd:name"=:v`, []string{"v"}},
+		{"where created_at::date=:day", []string{"day"}},
+		{"where arr[a:b]=:v", []string{"v"}},
+		{"where arr[1:n] && :tags", []string{"tags"}},
+		{"where arr[:n] is not null", nil},
+		{"where id=$1", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, param := range namedParams(tt.text) {
+			if tt.text[param.start:param.end] != ":"+param.name {
+				t.Errorf("namedParams(%q): %q at %d:%d", tt.text, param.name, param.start, param.end)
+			}
+			got = append(got, param.name)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("namedParams(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBindNamed(t *testing.T) {
+	type filter struct {
+		UserId int
+		Status string
+	}
+	tests := []struct {
+		name      string
+		args      []any
+		texts     []string
+		wantTexts []string
+		wantArgs  []any
+		wantErr   bool
+	}{
+		{
+			name:      "map",
+			args:      []any{map[string]any{"a": 1, "b": "x"}},
+			texts:     []string{" where a=:a and b=:b or a>:a"},
+			wantTexts: []string{" where a=$1 and b=$2 or a>$1"},
+			wantArgs:  []any{1, "x"},
+		},
+		{
+			name:      "struct shared numbering",
+			args:      []any{&filter{UserId: 7, Status: "done"}},
+			texts:     []string{"status=:status", " where user_id=:UserId and status<>:status"},
+			wantTexts: []string{"status=$1", " where user_id=$2 and status<>$1"},
+			wantArgs:  []any{"done", 7},
+		},
+		{
+			name:      "positional untouched",
+			args:      []any{1},
+			texts:     []string{" where id=$1"},
+			wantTexts: []string{" where id=$1"},
+			wantArgs:  []any{1},
+		},
+		{
+			name:      "array slice untouched",
+			args:      []any{map[string]any{"v": 1}},
+			texts:     []string{" where arr[1:n]=:v"},
+			wantTexts: []string{" where arr[1:n]=$1"},
+			wantArgs:  []any{1},
+		},
+		{
+			name:    "missing",
+			args:    []any{map[string]any{"a": 1}},
+			texts:   []string{" where a=:a and b=:b"},
+			wantErr: true,
+		},
+		{
+			name:    "mixed",
+			args:    []any{map[string]any{"a": 1}},
+			texts:   []string{" where a=:a and b=$2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		texts, args, e := bindNamed(tt.args, tt.texts...)
+		if (e != nil) != tt.wantErr {
+			t.Errorf("%s: error %v", tt.name, e)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(texts, tt.wantTexts) || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, texts, args, tt.wantTexts, tt.wantArgs)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package px
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PageRequest describes a keyset paginated query
+type PageRequest struct {
+	// SortKeys are columns like "create_time" or "create_time desc", defaulting to the primary key.
+	// Primary key columns are appended as tie-breakers. Sort key columns must not be null
+	SortKeys []string
+	// Cursor is Page.Next or Page.Prev of a previous page, empty for the first page
+	Cursor string
+	Size   int
+	// Where is an optional condition without order/limit clauses, with its Args
+	Where string
+	Args  []any
+}
+
+// Page is a page of documents, Next and Prev are empty if there is no such page
+type Page[T any] struct {
+	Items []T
+	Next  string
+	Prev  string
+}
+
+type pageCursor struct {
+	Prev bool              `json:"p,omitempty"`
+	Keys []json.RawMessage `json:"k"`
+}
+
+type sortKey struct {
+	column int
+	desc   bool
+}
+
+// Paginate returns a page of documents using keyset pagination, which stays fast on large tables unlike offset
+func (b *BaseModel[T]) Paginate(req PageRequest) (*Page[T], error) {
+	if req.Size < 1 {
+		return nil, errors.New("invalid page size:" + strconv.Itoa(req.Size))
+	}
+	keys, e := b.sortKeys(req.SortKeys)
+	if e != nil {
+		return nil, e
+	}
+
+	//cursor
+	var cursor *pageCursor
+	var values []any
+	if req.Cursor != "" {
+		cursor, values, e = b.decodeCursor(req.Cursor, keys)
+		if e != nil {
+			return nil, e
+		}
+	}
+	backward := cursor != nil && cursor.Prev
+
+	//where
+	where, args, e := b.bindWhere(req.Where, req.Args)
+	if e != nil {
+		return nil, e
+	}
+	if cursor != nil {
+		args = args[:len(args):len(args)]
+		builder := new(strings.Builder)
+		for i := range keys {
+			if i > 0 {
+				builder.WriteString(" or ")
+			}
+			builder.WriteString("(")
+			for j := 0; j <= i; j++ {
+				args = append(args, values[j])
+				column := b.TableName + "." + b.dbTags[keys[j].column]
+				op := "="
+				if j == i {
+					op = ">"
+					if keys[j].desc != backward {
+						op = "<"
+					}
+				}
+				if j > 0 {
+					builder.WriteString(" and ")
+				}
+				builder.WriteString(column + op + "$" + strconv.Itoa(len(args)))
+			}
+			builder.WriteString(")")
+		}
+		if where == "" {
+			where = " where (" + builder.String() + ")"
+		} else {
+			where = " where (" + strings.TrimSpace(strings.TrimSpace(where)[len("where"):]) + ") and (" + builder.String() + ")"
+		}
+	}
+
+	//order
+	orders := []string{}
+	for _, key := range keys {
+		direction := "asc"
+		if key.desc != backward {
+			direction = "desc"
+		}
+		orders = append(orders, b.TableName+"."+b.dbTags[key.column]+" "+direction)
+	}
+
+	_, query := b.GetSelectSQL()
+	query = query + where + " order by " + strings.Join(orders, ",") + " limit " + strconv.Itoa(req.Size+1)
+	items, e := b.queryAll(query, args)
+	if e != nil {
+		return nil, e
+	}
+
+	more := len(items) > req.Size
+	if more {
+		items = items[:req.Size]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := &Page[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	// going forward, there is a previous page unless this is the first one; going backward, there is always a next page
+	if more || backward {
+		page.Next, e = b.encodeCursor(&items[len(items)-1], keys, false)
+		if e != nil {
+			return nil, e
+		}
+	}
+	if (backward && more) || (!backward && cursor != nil) {
+		page.Prev, e = b.encodeCursor(&items[0], keys, true)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return page, nil
+}
+
+// sortKeys parses 'column [asc|desc]' sort keys, appending the primary key columns as tie-breakers
+func (b *BaseModel[T]) sortKeys(sortKeys []string) ([]sortKey, error) {
+	keys := []sortKey{}
+	used := make(map[int]bool)
+	for _, s := range sortKeys {
+		column, direction, _ := strings.Cut(strings.TrimSpace(s), " ")
+		i := b.columnIndex(column)
+		if i == -1 {
+			return nil, errors.New("unknown sort column '" + column + "' for table " + b.TableName)
+		}
+		key := sortKey{column: i}
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "", "asc":
+		case "desc":
+			key.desc = true
+		default:
+			return nil, errors.New("invalid sort direction in '" + s + "'")
+		}
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		keys = append(keys, key)
+	}
+	for _, i := range b.primaryKeys {
+		if !used[i] {
+			keys = append(keys, sortKey{column: i})
+		}
+	}
+	return keys, nil
+}
+
+func (b *BaseModel[T]) encodeCursor(v *T, keys []sortKey, prev bool) (string, error) {
+	value := reflect.ValueOf(v).Elem()
+	cursor := pageCursor{Prev: prev}
+	for _, key := range keys {
+		raw, e := json.Marshal(b.field(value, key.column).Interface())
+		if e != nil {
+			return "", e
+		}
+		cursor.Keys = append(cursor.Keys, raw)
+	}
+	data, e := json.Marshal(cursor)
+	if e != nil {
+		return "", e
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor decodes the cursor token, converting its key values to the sort keys' field types
+func (b *BaseModel[T]) decodeCursor(token string, keys []sortKey) (*pageCursor, []any, error) {
+	data, e := base64.RawURLEncoding.DecodeString(token)
+	if e != nil {
+		return nil, nil, errors.New("invalid cursor")
+	}
+	cursor := &pageCursor{}
+	e = json.Unmarshal(data, cursor)
+	if e != nil || len(cursor.Keys) != len(keys) {
+		return nil, nil, errors.New("invalid cursor")
+	}
+
+	values := []any{}
+	for i, key := range keys {
+		v := reflect.New(b.Type.Field(b.fields[key.column]).Type)
+		e = json.Unmarshal(cursor.Keys[i], v.Interface())
+		if e != nil {
+			return nil, nil, errors.New("invalid cursor")
+		}
+		values = append(values, v.Elem().Interface())
+	}
+	return cursor, values, nil
+}
@@ -0,0 +1,82 @@
+package px
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testDsn points nowhere, pgxpool connects lazily and AutoSyncTableSchema is off, so models build without a database
+const testDsn = "postgres://localhost:1/pxtest"
+
+type pageArticle struct {
+	Id         uint32
+	Title      string
+	Score      float64
+	CreateTime time.Time
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	b, e := NewBaseModel[pageArticle](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer b.Pool.Close()
+	keys, e := b.sortKeys([]string{"create_time desc", "score"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(keys) != 3 || !keys[0].desc || keys[2].column != 0 {
+		t.Fatalf("sortKeys = %+v, want create_time desc, score, id", keys)
+	}
+
+	v := &pageArticle{Id: 42, Title: "t", Score: 1.5, CreateTime: time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)}
+	for _, prev := range []bool{false, true} {
+		token, e := b.encodeCursor(v, keys, prev)
+		if e != nil {
+			t.Fatal(e)
+		}
+		cursor, values, e := b.decodeCursor(token, keys)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if cursor.Prev != prev {
+			t.Errorf("Prev = %v, want %v", cursor.Prev, prev)
+		}
+		want := []any{v.CreateTime, v.Score, v.Id}
+		if !reflect.DeepEqual(values, want) {
+			t.Errorf("values = %#v, want %#v", values, want)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	b, e := NewBaseModel[pageArticle](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer b.Pool.Close()
+	keys, e := b.sortKeys(nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	other, e := b.sortKeys([]string{"score"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	token, e := b.encodeCursor(&pageArticle{Id: 1}, other, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	for _, token := range []string{
+		"not base64!",
+		"bm90IGpzb24",          // not json
+		"eyJrIjpbIlwieFwiIl19", // {"k":["\"x\""]}, a string for the uint32 id
+		token,                  // encoded for other sort keys
+	} {
+		if _, _, e := b.decodeCursor(token, keys); e == nil {
+			t.Errorf("decodeCursor(%q) succeeded", token)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package px
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// plan holds the model's statements, built once so queries don't rebuild SQL strings
+type plan struct {
+	schema, table string // the Schema and TableName the statements were built for
+
+	selectArgs   []int
+	selectSQL    string
+	selectFields string
+	insertArgs   []int
+	insertSQL    string
+	returningSQL string
+	updateArgs   []int
+	updateSQL    string
+	upsertArgs   []int
+	upsertSQL    string
+	generated    bool // *T implements Generated
+
+	// rebuilt is the plan for the Schema and TableName a model sharing this plan was changed to, built once
+	rebuilt atomic.Pointer[plan]
+}
+
+func (b *BaseModel[T]) buildPlan() *plan {
+	p := &plan{schema: b.Schema, table: b.TableName}
+	p.selectArgs, p.selectSQL = b.buildSelectSQL()
+	_, p.selectFields = b.buildSelectFields()
+	p.insertArgs, p.insertSQL = b.buildInsertSQL()
+	p.returningSQL = p.insertSQL + " returning " + strings.Join(b.PrimaryKeys(), ",")
+	p.updateArgs, p.updateSQL = b.buildUpdateSQL()
+	p.upsertArgs, p.upsertSQL = b.buildUpsertSQL()
+	p.generated = b.detectGenerated()
+	return p
+}
+
+// cached returns the plan, or the one for the current Schema and TableName if they were changed since NewBaseModel.
+// That one is kept on the shared plan, so it's built once rather than per query
+func (b *BaseModel[T]) cached() *plan {
+	if b.plan == nil {
+		return b.buildPlan()
+	}
+	if b.plan.schema == b.Schema && b.plan.table == b.TableName {
+		return b.plan
+	}
+	if p := b.plan.rebuilt.Load(); p != nil && p.schema == b.Schema && p.table == b.TableName {
+		return p
+	}
+	p := b.buildPlan()
+	b.plan.rebuilt.Store(p)
+	return p
+}
+
+// GetInsertSQL returns insert SQL without returning id
+func (b *BaseModel[T]) GetInsertSQL() ([]int, string) {
+	p := b.cached()
+	return p.insertArgs, p.insertSQL
+}
+
+// GetInsertReturningSQL returns insert SQL with returning the primary key columns
+func (b *BaseModel[T]) GetInsertReturningSQL() ([]int, string) {
+	p := b.cached()
+	return p.insertArgs, p.returningSQL
+}
+
+// GetSelectSQL returns fieldIndexes, and select SQL
+func (b *BaseModel[T]) GetSelectSQL() ([]int, string) {
+	p := b.cached()
+	return p.selectArgs, p.selectSQL
+}
+
+// GetSelectFields returns fieldIndexes, and the select list: id,name,create_at
+func (b *BaseModel[T]) GetSelectFields() ([]int, string) {
+	p := b.cached()
+	return p.selectArgs, p.selectFields
+}
+
+// GetUpdateSQL returns update SQL setting every non primary key column except px:"created", filtered by the full primary key
+// (and the current px:"version"), returning the px:"updated" and px:"version" columns if any
+func (b *BaseModel[T]) GetUpdateSQL() ([]int, string) {
+	p := b.cached()
+	return p.updateArgs, p.updateSQL
+}
+
+// GetUpsertSQL returns insert SQL of every column that updates the non primary key columns on primary key conflict,
+// returning the primary key columns
+func (b *BaseModel[T]) GetUpsertSQL() ([]int, string) {
+	p := b.cached()
+	return p.upsertArgs, p.upsertSQL
+}
@@ -0,0 +1,97 @@
+package px
+
+import (
+	"testing"
+	"time"
+)
+
+type benchUser struct {
+	Id         uint32
+	Name       string
+	Email      string
+	Age        int64
+	Active     bool
+	CreateTime time.Time
+}
+
+// benchGenerated is benchUser with the methods 'px gen' writes
+type benchGenerated benchUser
+
+func (v *benchGenerated) PxColumns() []string {
+	return []string{"id", "name", "email", "age", "active", "create_time"}
+}
+
+func (v *benchGenerated) PxColumnPointers() []any {
+	return []any{&v.Id, &v.Name, &v.Email, &v.Age, &v.Active, &v.CreateTime}
+}
+
+func (v *benchGenerated) PxColumnValues() []any {
+	return []any{v.Id, v.Name, v.Email, v.Age, v.Active, v.CreateTime}
+}
+
+// benchRow is a row as a driver would decode it
+var benchRow = []any{uint32(7), "ada", "ada@example.com", int64(36), true, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+// scanRow copies row into the field pointers, like rows.Scan
+func scanRow(dst []any, row []any) {
+	for i, p := range dst {
+		switch p := p.(type) {
+		case *uint32:
+			*p = row[i].(uint32)
+		case *string:
+			*p = row[i].(string)
+		case *int64:
+			*p = row[i].(int64)
+		case *bool:
+			*p = row[i].(bool)
+		case *time.Time:
+			*p = row[i].(time.Time)
+		}
+	}
+}
+
+func benchModel[T any](b *testing.B) *BaseModel[T] {
+	model, e := NewBaseModel[T](testDsn)
+	if e != nil {
+		b.Fatal(e)
+	}
+	b.Cleanup(model.Pool.Close)
+	return model
+}
+
+func BenchmarkScanReflect(b *testing.B) {
+	model := benchModel[benchUser](b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := new(benchUser)
+		scanRow(model.fieldArgs(v), benchRow)
+	}
+}
+
+func BenchmarkScanGenerated(b *testing.B) {
+	model := benchModel[benchGenerated](b)
+	if !model.cached().generated {
+		b.Fatal("generated code not detected")
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := new(benchGenerated)
+		scanRow(model.fieldArgs(v), benchRow)
+	}
+}
+
+func BenchmarkSelectSQLBuild(b *testing.B) {
+	model := benchModel[benchUser](b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		model.buildSelectSQL()
+	}
+}
+
+func BenchmarkSelectSQLCached(b *testing.B) {
+	model := benchModel[benchUser](b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		model.GetSelectSQL()
+	}
+}
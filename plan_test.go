@@ -0,0 +1,33 @@
+package px
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCachedAfterTableNameChange(t *testing.T) {
+	model, e := NewBaseModel[benchUser](testDsn)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer model.Pool.Close()
+	initial := model.cached()
+
+	model.TableName = "people"
+	p := model.cached()
+	if p == initial || !strings.Contains(p.selectSQL, "people") {
+		t.Fatalf("plan not rebuilt for the new table name: %s", p.selectSQL)
+	}
+	if model.cached() != p {
+		t.Error("plan rebuilt again for an unchanged table name")
+	}
+
+	copied := model.Unscoped()
+	if copied.cached() != p {
+		t.Error("copy doesn't share the rebuilt plan")
+	}
+	model.TableName = "bench_users"
+	if model.cached() != initial {
+		t.Error("original table name doesn't use the initial plan")
+	}
+}
@@ -0,0 +1,316 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// setPrimaryKeys resolves the primary key columns from the 'group=pkey...' index model, defaulting to the first field
+func (b *BaseModel[T]) setPrimaryKeys(primaryKeyModel *indexModel) error {
+	if primaryKeyModel == nil {
+		b.primaryKeys = []int{0}
+		return nil
+	}
+
+	b.primaryKeys = nil
+	for _, key := range primaryKeyModel.keys {
+		i := b.columnIndex(key.key)
+		if i == -1 {
+			return errors.New("primary key column '" + key.key + "' not found in " + b.Type.String())
+		}
+		b.primaryKeys = append(b.primaryKeys, i)
+	}
+	sort.Ints(b.primaryKeys)
+
+	// keep the generated DDL in field order as well
+	sort.SliceStable(primaryKeyModel.keys, func(i, j int) bool {
+		return b.columnIndex(primaryKeyModel.keys[i].key) < b.columnIndex(primaryKeyModel.keys[j].key)
+	})
+	return nil
+}
+
+// columnIndex returns the field index of column dbTag, or -1
+func (b *BaseModel[T]) columnIndex(dbTag string) int {
+	for i, v := range b.dbTags {
+		if v == dbTag {
+			return i
+		}
+	}
+	return -1
+}
+
+// PrimaryKeys returns the primary key columns, in field order
+func (b *BaseModel[T]) PrimaryKeys() []string {
+	out := []string{}
+	for _, i := range b.primaryKeys {
+		out = append(out, b.dbTags[i])
+	}
+	return out
+}
+
+// keyWhere returns ' where a=$n and b=$n+1' for the primary key columns, numbering placeholders from start
+func (b *BaseModel[T]) keyWhere(start int) string {
+	builder := new(strings.Builder)
+	builder.WriteString(" where ")
+	for n, i := range b.primaryKeys {
+		if n > 0 {
+			builder.WriteString(" and ")
+		}
+		builder.WriteString(b.dbTags[i] + "=$" + strconv.Itoa(start+n))
+	}
+	return builder.String()
+}
+
+// keyArgs accepts either a T/*T value, or all primary key values in field order
+func (b *BaseModel[T]) keyArgs(keys []any) ([]any, error) {
+	if len(keys) == 1 {
+		value := reflect.ValueOf(keys[0])
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.IsValid() && value.Type() == b.Type {
+			args := []any{}
+			for _, i := range b.primaryKeys {
+				args = append(args, b.field(value, i).Interface())
+			}
+			return args, nil
+		}
+	}
+	if len(keys) != len(b.primaryKeys) {
+		return nil, errors.New("expected " + strconv.Itoa(len(b.primaryKeys)) + " primary key values (" + strings.Join(b.PrimaryKeys(), ",") + ") for table " + b.TableName + ", got " + strconv.Itoa(len(keys)))
+	}
+	return keys, nil
+}
+
+// FindByKey finds a document (*struct type) by its full primary key, given as a struct or as ordered values
+func (b *BaseModel[T]) FindByKey(keys ...any) (*T, error) {
+	args, e := b.keyArgs(keys)
+	if e != nil {
+		return nil, e
+	}
+
+	_, query := b.GetSelectSQL()
+	return b.queryOne(query+b.scope(b.keyWhere(1)), args)
+}
+
+// ExistsByKey checks whether a document with the full primary key exists
+func (b *BaseModel[T]) ExistsByKey(keys ...any) (bool, error) {
+	args, e := b.keyArgs(keys)
+	if e != nil {
+		return false, e
+	}
+
+	//scan
+	num := 0
+	query := `select 1 from ` + b.TableName + b.scope(b.keyWhere(1)) + ` limit 1`
+	e = b.Executor().QueryRow(context.Background(), query, args...).Scan(&num)
+	if e != nil {
+		return false, b.toError(e, query)
+	}
+	return num > 0, nil
+}
+
+// DeleteByKey deletes the document with the full primary key, or marks it deleted if the model has a px:"softdelete" field
+func (b *BaseModel[T]) DeleteByKey(keys ...any) (int64, error) {
+	args, e := b.keyArgs(keys)
+	if e != nil {
+		return 0, e
+	}
+
+	return b.deleteWhere(b.scope(b.keyWhere(1)), args)
+}
+
+// buildUpdateSQL builds GetUpdateSQL's statement
+func (b *BaseModel[T]) buildUpdateSQL() ([]int, string) {
+	builder := new(strings.Builder)
+	builder.WriteString(`update ` + b.Schema + `.` + b.TableName + ` set `)
+
+	argsIndex := []int{}
+	for i, dbTag := range b.dbTags {
+		if b.isPrimaryKey(i) || i == b.created || i == b.version {
+			continue
+		}
+		if len(argsIndex) > 0 {
+			builder.WriteString(",")
+		}
+		argsIndex = append(argsIndex, i)
+		if i == b.updated {
+			builder.WriteString(dbTag + "=coalesce($" + strconv.Itoa(len(argsIndex)) + ",now())")
+			continue
+		}
+		builder.WriteString(dbTag + "=$" + strconv.Itoa(len(argsIndex)))
+	}
+	if b.version != -1 {
+		if len(argsIndex) > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(b.dbTags[b.version] + "=" + b.dbTags[b.version] + "+1")
+	}
+
+	builder.WriteString(b.keyWhere(len(argsIndex) + 1))
+	argsIndex = append(argsIndex, b.primaryKeys...)
+	if b.version != -1 {
+		argsIndex = append(argsIndex, b.version)
+		builder.WriteString(" and " + b.dbTags[b.version] + "=$" + strconv.Itoa(len(argsIndex)))
+	}
+
+	returning := []string{}
+	for _, i := range b.updateReturning() {
+		returning = append(returning, b.dbTags[i])
+	}
+	if len(returning) > 0 {
+		builder.WriteString(" returning " + strings.Join(returning, ","))
+	}
+	return argsIndex, builder.String()
+}
+
+// updateReturning returns the field indexes Update writes back to v
+func (b *BaseModel[T]) updateReturning() []int {
+	out := []int{}
+	for _, i := range []int{b.updated, b.version} {
+		if i != -1 {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (b *BaseModel[T]) isPrimaryKey(fieldIndex int) bool {
+	for _, i := range b.primaryKeys {
+		if i == fieldIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// Update updates every non primary key column of v, matching on the full primary key. The px:"updated" field of v is refreshed.
+// With a px:"version" field, the row must still have v's version, which is incremented, otherwise ErrStaleObject is returned
+func (b *BaseModel[T]) Update(v *T) (int64, error) {
+	if len(b.primaryKeys) == len(b.dbTags) {
+		return 0, errors.New("table " + b.TableName + " has no column to update")
+	}
+	value := reflect.ValueOf(v).Elem()
+
+	//hook
+	e := b.beforeUpdate(v)
+	if e != nil {
+		return 0, e
+	}
+
+	//args
+	argsIndex, query := b.GetUpdateSQL()
+	values := b.columnValues(value)
+	args := []any{}
+	for _, i := range argsIndex {
+		if i == b.updated {
+			args = append(args, autoTime())
+			continue
+		}
+		args = append(args, b.columnValue(values, value, i))
+	}
+
+	//exec
+	if returning := b.updateReturning(); len(returning) > 0 {
+		fieldArgs := []any{}
+		for _, i := range returning {
+			fieldArgs = append(fieldArgs, b.field(value, i).Addr().Interface())
+		}
+		e = b.Executor().QueryRow(context.Background(), query, args...).Scan(fieldArgs...)
+		if e != nil {
+			e = b.toError(e, query)
+			if errors.Is(e, ErrNotFound) {
+				if b.version != -1 {
+					return 0, ErrStaleObject
+				}
+				return 0, nil
+			}
+			return 0, e
+		}
+		return 1, b.afterUpdate(v)
+	}
+	result, e := b.Executor().Exec(context.Background(), query, args...)
+	if e != nil {
+		return 0, b.toError(e, query)
+	}
+	if result.RowsAffected() == 0 {
+		return 0, nil
+	}
+	return result.RowsAffected(), b.afterUpdate(v)
+}
+
+// buildUpsertSQL builds GetUpsertSQL's statement
+func (b *BaseModel[T]) buildUpsertSQL() ([]int, string) {
+	builder := new(strings.Builder)
+	builder.WriteString(`insert into ` + b.Schema + `.` + b.TableName + ` (` + strings.Join(b.dbTags, ",") + `) values (`)
+
+	argsIndex := []int{}
+	sets := []string{}
+	for i, dbTag := range b.dbTags {
+		argsIndex = append(argsIndex, i)
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		if b.isAutoTime(i) {
+			builder.WriteString("coalesce($" + strconv.Itoa(len(argsIndex)) + ",now())")
+		} else {
+			builder.WriteString("$" + strconv.Itoa(len(argsIndex)))
+		}
+		if i == b.version {
+			sets = append(sets, dbTag+"="+b.TableName+"."+dbTag+"+1")
+		} else if !b.isPrimaryKey(i) && i != b.created {
+			sets = append(sets, dbTag+"=excluded."+dbTag)
+		}
+	}
+	if len(sets) == 0 {
+		// keep 'returning' working when there is nothing to update
+		sets = append(sets, b.dbTags[b.primaryKeys[0]]+"=excluded."+b.dbTags[b.primaryKeys[0]])
+	}
+
+	builder.WriteString(`) on conflict (` + strings.Join(b.PrimaryKeys(), ",") + `) do update set ` + strings.Join(sets, ","))
+	builder.WriteString(` returning ` + strings.Join(b.PrimaryKeys(), ","))
+	return argsIndex, builder.String()
+}
+
+// Upsert inserts v, or updates the existing row with the same primary key. A serial primary key left zero always inserts.
+// Insert hooks are called. It returns the primary key like Insert does
+func (b *BaseModel[T]) Upsert(v T) (any, error) {
+	value := reflect.ValueOf(&v).Elem()
+	for _, i := range b.primaryKeys {
+		if strings.Contains(b.pgTypes[i], "serial") && b.field(value, i).IsZero() {
+			return b.Insert(v)
+		}
+	}
+
+	//hook
+	e := b.beforeInsert(&v)
+	if e != nil {
+		return nil, e
+	}
+
+	//args
+	argsIndex, query := b.GetUpsertSQL()
+	values := b.columnValues(value)
+	args := []any{}
+	for _, i := range argsIndex {
+		if i == b.updated || (i == b.created && b.field(value, i).IsZero()) {
+			args = append(args, autoTime())
+			continue
+		}
+		args = append(args, b.columnValue(values, value, i))
+	}
+
+	keys, e := b.queryKeys(query, args, value)
+	if e != nil {
+		return nil, e
+	}
+	e = b.afterInsert(&v)
+	if e != nil {
+		return nil, e
+	}
+	return keys, nil
+}
@@ -0,0 +1,105 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// projection maps the exported fields of P to the model's columns by their snake_case name
+func projection[P any, T any](b *BaseModel[T]) ([]int, string, error) {
+	var p P
+	t := reflect.TypeOf(p)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, "", errors.New("projection type must be struct type")
+	}
+
+	fields := []int{}
+	columns := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		dbTag := strcase.ToSnake(field.Name)
+		if b.columnIndex(dbTag) == -1 {
+			return nil, "", errors.New("field " + t.String() + "." + field.Name + " has no column '" + dbTag + "' in table " + b.TableName)
+		}
+		fields = append(fields, i)
+		columns = append(columns, b.TableName+"."+dbTag)
+	}
+	if len(fields) == 0 {
+		return nil, "", errors.New(t.String() + " has no exported field")
+	}
+	return fields, `select ` + strings.Join(columns, ",") + ` from ` + b.TableName, nil
+}
+
+func projectionArgs[P any](v *P, fields []int) []any {
+	value := reflect.ValueOf(v).Elem()
+	out := make([]any, 0, len(fields))
+	for _, i := range fields {
+		out = append(out, value.Field(i).Addr().Interface())
+	}
+	return out
+}
+
+// QueryAs queries the documents of model b that match 'where' condition into P, selecting only the columns matching P's fields:
+//
+//	names, e := px.QueryAs[UserName](users, "create_time > $1", since)
+func QueryAs[P any, T any](b *BaseModel[T], where string, args ...any) ([]P, error) {
+	fields, query, e := projection[P](b)
+	if e != nil {
+		return nil, e
+	}
+	where, args, e = b.bindWhere(where, args)
+	if e != nil {
+		return nil, e
+	}
+
+	//query
+	query = query + where
+	rows, e := b.Executor().Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	vs := make([]P, 0, 2)
+	for rows.Next() {
+		var v P
+		e = rows.Scan(projectionArgs(&v, fields)...)
+		if e != nil {
+			break
+		}
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, b.toError(e, query)
+	}
+	return vs, nil
+}
+
+// SelectInto finds a document of model b that matches 'where' condition into P, like QueryAs
+func SelectInto[P any, T any](b *BaseModel[T], where string, args ...any) (*P, error) {
+	fields, query, e := projection[P](b)
+	if e != nil {
+		return nil, e
+	}
+	where, args, e = b.bindWhere(where, args)
+	if e != nil {
+		return nil, e
+	}
+
+	query = query + where
+	v := new(P)
+	e = b.Executor().QueryRow(context.Background(), query, args...).Scan(projectionArgs(v, fields)...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+	return v, nil
+}
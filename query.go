@@ -0,0 +1,365 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Cond is a where condition built by Eq, In, And, Or etc. Column names are validated when the query runs
+type Cond struct {
+	op     string
+	column string
+	value  any
+	conds  []Cond
+}
+
+func Eq(column string, value any) Cond    { return Cond{op: "=", column: column, value: value} }
+func Ne(column string, value any) Cond    { return Cond{op: "<>", column: column, value: value} }
+func Gt(column string, value any) Cond    { return Cond{op: ">", column: column, value: value} }
+func Gte(column string, value any) Cond   { return Cond{op: ">=", column: column, value: value} }
+func Lt(column string, value any) Cond    { return Cond{op: "<", column: column, value: value} }
+func Lte(column string, value any) Cond   { return Cond{op: "<=", column: column, value: value} }
+func Like(column string, value any) Cond  { return Cond{op: "like", column: column, value: value} }
+func ILike(column string, value any) Cond { return Cond{op: "ilike", column: column, value: value} }
+
+// In matches column against a slice of values, as column = any($n)
+func In(column string, values any) Cond { return Cond{op: "in", column: column, value: values} }
+func IsNull(column string) Cond         { return Cond{op: "is null", column: column} }
+func IsNotNull(column string) Cond      { return Cond{op: "is not null", column: column} }
+func And(conds ...Cond) Cond            { return Cond{op: "and", conds: conds} }
+func Or(conds ...Cond) Cond             { return Cond{op: "or", conds: conds} }
+func Not(cond Cond) Cond                { return Cond{op: "not", conds: []Cond{cond}} }
+
+// build writes the condition, appending its values to args and numbering placeholders after them
+func (c Cond) build(builder *strings.Builder, args *[]any, column func(string) (string, error)) error {
+	switch c.op {
+	case "and", "or":
+		if len(c.conds) == 0 {
+			if c.op == "and" {
+				builder.WriteString("true")
+			} else {
+				builder.WriteString("false")
+			}
+			return nil
+		}
+		builder.WriteString("(")
+		for i, cond := range c.conds {
+			if i > 0 {
+				builder.WriteString(" " + c.op + " ")
+			}
+			e := cond.build(builder, args, column)
+			if e != nil {
+				return e
+			}
+		}
+		builder.WriteString(")")
+		return nil
+	case "not":
+		builder.WriteString("not ")
+		return c.conds[0].build(builder, args, column)
+	case "":
+		return errors.New("empty condition")
+	}
+
+	name, e := column(c.column)
+	if e != nil {
+		return e
+	}
+	switch c.op {
+	case "is null", "is not null":
+		builder.WriteString(name + " " + c.op)
+	case "in":
+		*args = append(*args, c.value)
+		builder.WriteString(name + " = any($" + strconv.Itoa(len(*args)) + ")")
+	default:
+		*args = append(*args, c.value)
+		builder.WriteString(name + " " + c.op + " $" + strconv.Itoa(len(*args)))
+	}
+	return nil
+}
+
+// Query is a composable query on a BaseModel's table, created by BaseModel.Select
+type Query[T any] struct {
+	model   *BaseModel[T]
+	columns []string
+	joins   []queryJoin
+	conds   []Cond
+	orders  []string
+	limit   int
+	offset  int
+}
+
+// Select starts a query on the model's table. All and One fill only the given columns' fields if any, leaving the others zero
+func (b *BaseModel[T]) Select(columns ...string) *Query[T] {
+	return &Query[T]{
+		model:   b,
+		columns: columns,
+		limit:   -1,
+		offset:  -1,
+	}
+}
+
+// Where adds conditions, combined with 'and'
+func (q *Query[T]) Where(conds ...Cond) *Query[T] {
+	q.conds = append(q.conds, conds...)
+	return q
+}
+
+// And is an alias of Where
+func (q *Query[T]) And(conds ...Cond) *Query[T] {
+	return q.Where(conds...)
+}
+
+// Or combines the conditions so far with 'or' (conds joined with 'and')
+func (q *Query[T]) Or(conds ...Cond) *Query[T] {
+	q.conds = []Cond{Or(And(q.conds...), And(conds...))}
+	return q
+}
+
+// OrderBy adds order columns, each as 'column' or 'column desc'
+func (q *Query[T]) OrderBy(orders ...string) *Query[T] {
+	q.orders = append(q.orders, orders...)
+	return q
+}
+
+// Preload loads the named relations of the queried documents, see BaseModel.Preload
+func (q *Query[T]) Preload(relations ...string) *Query[T] {
+	q.model = q.model.Preload(relations...)
+	return q
+}
+
+func (q *Query[T]) Limit(n int) *Query[T] {
+	q.limit = n
+	return q
+}
+
+func (q *Query[T]) Offset(n int) *Query[T] {
+	q.offset = n
+	return q
+}
+
+// column validates name (column or table.column) against the model's columns and returns it qualified
+func (q *Query[T]) column(name string) (string, error) {
+	column := name
+	if table, c, ok := strings.Cut(name, "."); ok {
+		if table != q.model.TableName {
+			if !q.joinedColumn(table, c) {
+				return "", errors.New("unknown column '" + name + "'")
+			}
+			return name, nil
+		}
+		column = c
+	}
+	if q.model.columnIndex(column) == -1 {
+		return "", errors.New("unknown column '" + name + "' for table " + q.model.TableName)
+	}
+	return q.model.TableName + "." + column, nil
+}
+
+// where returns the soft delete scoped where clause and its args, numbering placeholders after args
+func (q *Query[T]) where(args []any) (string, []any, error) {
+	if len(q.conds) == 0 {
+		return q.model.scope(""), args, nil
+	}
+	builder := new(strings.Builder)
+	e := And(q.conds...).build(builder, &args, q.column)
+	if e != nil {
+		return "", nil, e
+	}
+	return q.model.scope(" where " + builder.String()), args, nil
+}
+
+// trail returns the order by, limit and offset clauses
+func (q *Query[T]) trail() (string, error) {
+	builder := new(strings.Builder)
+	for i, order := range q.orders {
+		name, direction, _ := strings.Cut(strings.TrimSpace(order), " ")
+		column, e := q.column(name)
+		if e != nil {
+			return "", e
+		}
+		direction = strings.ToLower(strings.TrimSpace(direction))
+		switch direction {
+		case "":
+			direction = "asc"
+		case "asc", "desc":
+		default:
+			return "", errors.New("invalid order direction '" + direction + "' in " + order)
+		}
+		if i == 0 {
+			builder.WriteString(" order by ")
+		} else {
+			builder.WriteString(",")
+		}
+		builder.WriteString(column + " " + direction)
+	}
+	if q.limit > -1 {
+		builder.WriteString(" limit " + strconv.Itoa(q.limit))
+	}
+	if q.offset > -1 {
+		builder.WriteString(" offset " + strconv.Itoa(q.offset))
+	}
+	return builder.String(), nil
+}
+
+// selectSQL returns the selected field indexes (nil for every column) and the select SQL
+func (q *Query[T]) selectSQL() ([]int, string, error) {
+	if len(q.columns) == 0 {
+		from, e := q.from()
+		if e != nil {
+			return nil, "", e
+		}
+		_, selection := q.model.GetSelectFields()
+		return nil, `select ` + selection + from, nil
+	}
+	fields := []int{}
+	columns := []string{}
+	for _, name := range q.columns {
+		column, e := q.column(name)
+		if e != nil {
+			return nil, "", e
+		}
+		if !strings.HasPrefix(column, q.model.TableName+".") {
+			return nil, "", errors.New("column '" + name + "' is not a column of " + q.model.TableName + ", use JoinAll to select joined tables")
+		}
+		fields = append(fields, q.model.columnIndex(strings.TrimPrefix(column, q.model.TableName+".")))
+		columns = append(columns, column)
+	}
+	from, e := q.from()
+	if e != nil {
+		return nil, "", e
+	}
+	return fields, `select ` + strings.Join(columns, ",") + from, nil
+}
+
+// All returns every matching document
+func (q *Query[T]) All() ([]T, error) {
+	where, args, e := q.where(nil)
+	if e != nil {
+		return nil, e
+	}
+	trail, e := q.trail()
+	if e != nil {
+		return nil, e
+	}
+	fields, query, e := q.selectSQL()
+	if e != nil {
+		return nil, e
+	}
+	return q.model.queryAllFields(query+where+trail, args, fields)
+}
+
+// One returns the first matching document, or ErrNotFound
+func (q *Query[T]) One() (*T, error) {
+	where, args, e := q.where(nil)
+	if e != nil {
+		return nil, e
+	}
+	limit := q.limit
+	q.limit = 1
+	trail, e := q.trail()
+	q.limit = limit
+	if e != nil {
+		return nil, e
+	}
+	fields, query, e := q.selectSQL()
+	if e != nil {
+		return nil, e
+	}
+	return q.model.queryOneFields(query+where+trail, args, fields)
+}
+
+// Count counts the matching documents, ignoring order, limit and offset
+func (q *Query[T]) Count() (int64, error) {
+	where, args, e := q.where(nil)
+	if e != nil {
+		return 0, e
+	}
+
+	from, e := q.from()
+	if e != nil {
+		return 0, e
+	}
+
+	var num int64
+	query := `select count(*) as count` + from + where
+	e = q.model.Executor().QueryRow(context.Background(), query, args...).Scan(&num)
+	if e != nil {
+		return 0, q.model.toError(e, query)
+	}
+	return num, nil
+}
+
+// Exists reports whether any document matches, it returns false rather than ErrNotFound if none does
+func (q *Query[T]) Exists() (bool, error) {
+	where, args, e := q.where(nil)
+	if e != nil {
+		return false, e
+	}
+
+	from, e := q.from()
+	if e != nil {
+		return false, e
+	}
+
+	query := `select exists (select 1` + from + where + `)`
+	exists := false
+	e = q.model.Executor().QueryRow(context.Background(), query, args...).Scan(&exists)
+	if e != nil {
+		return false, q.model.toError(e, query)
+	}
+	return exists, nil
+}
+
+// Delete deletes the matching documents, or marks them deleted if the model has a px:"softdelete" field
+func (q *Query[T]) Delete() (int64, error) {
+	if len(q.joins) > 0 {
+		return 0, errors.New("delete with joins is not supported")
+	}
+	where, args, e := q.where(nil)
+	if e != nil {
+		return 0, e
+	}
+	return q.model.deleteWhere(where, args)
+}
+
+// Update sets columns of the matching documents, px:"updated" and px:"version" columns are maintained like UpdateSet does
+func (q *Query[T]) Update(sets map[string]any) (int64, error) {
+	if len(sets) == 0 {
+		return 0, errors.New("no column to update")
+	}
+	if len(q.joins) > 0 {
+		return 0, errors.New("update with joins is not supported")
+	}
+	columns := []string{}
+	for column := range sets {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	args := []any{}
+	assignments := []string{}
+	for _, column := range columns {
+		if q.model.columnIndex(column) == -1 {
+			return 0, errors.New("unknown column '" + column + "' for table " + q.model.TableName)
+		}
+		args = append(args, sets[column])
+		assignments = append(assignments, column+"=$"+strconv.Itoa(len(args)))
+	}
+
+	where, args, e := q.where(args)
+	if e != nil {
+		return 0, e
+	}
+	set, args := q.model.autoUpdateSet(strings.Join(assignments, ","), args)
+
+	query := `update ` + q.model.TableName + ` set ` + set + where
+	result, e := q.model.Executor().Exec(context.Background(), query, args...)
+	if e != nil {
+		return 0, q.model.toError(e, query)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,53 @@
+package px
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+var (
+	registryLock sync.Mutex
+	registry     []func(dsn string) (SchemaSyncer, error)
+)
+
+// Register adds model T to the models the px command line tool syncs, options run after the model is created to set
+// its TableName or Schema. Call it from an init function of the package the tool loads, see cmd/px
+func Register[T any](options ...func(*BaseModel[T])) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry = append(registry, func(dsn string) (SchemaSyncer, error) {
+		model, e := NewBaseModel[T](dsn)
+		if e != nil {
+			return nil, e
+		}
+		for _, option := range options {
+			option(model)
+		}
+		return model, nil
+	})
+}
+
+// OpenRegistered creates the registered models on dsn, with AutoSyncTableSchema turned off
+func OpenRegistered(dsn string) ([]SchemaSyncer, error) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if len(registry) == 0 {
+		return nil, errors.New("no model registered, see px.Register")
+	}
+
+	autoSync := AutoSyncTableSchema
+	AutoSyncTableSchema = false
+	defer func() { AutoSyncTableSchema = autoSync }()
+
+	out := []SchemaSyncer{}
+	for _, open := range registry {
+		model, e := open(dsn)
+		if e != nil {
+			log.Println(e)
+			return nil, e
+		}
+		out = append(out, model)
+	}
+	return out, nil
+}
@@ -0,0 +1,375 @@
+package px
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"reflect"
+)
+
+// relation loads a declared relation's field for a batch of parents
+type relation[T any] interface {
+	load(tx Executor, parents []T) error
+	// joinOn returns the target table and the join condition, or empty strings if the relation can't be joined directly
+	joinOn() (string, string)
+}
+
+type (
+	belongsTo[T, R any] struct {
+		parent *BaseModel[T]
+		target *BaseModel[R]
+		field  int // struct field index in T
+		fk     int // column index in T
+	}
+	hasMany[T, R any] struct {
+		parent *BaseModel[T]
+		target *BaseModel[R]
+		field  int // struct field index in T
+		fk     int // column index in R
+	}
+	manyToMany[T, R any] struct {
+		parent       *BaseModel[T]
+		target       *BaseModel[R]
+		field        int // struct field index in T
+		joinTable    string
+		joinFK       string
+		joinTargetFK string
+	}
+)
+
+// BelongsTo declares that field (of type R or *R, tagged px:"-") of b's documents references a target document,
+// through b's foreign key column fk matching target's primary key
+func BelongsTo[T, R any](b *BaseModel[T], field string, target *BaseModel[R], fk string) error {
+	f, e := b.relationField(field, target.Type, false)
+	if e != nil {
+		return e
+	}
+	if len(target.primaryKeys) != 1 {
+		return errors.New("relation " + field + ": target table " + target.TableName + " has a composite primary key")
+	}
+	i := b.columnIndex(fk)
+	if i == -1 {
+		return errors.New("relation " + field + ": unknown column '" + fk + "' for table " + b.TableName)
+	}
+	b.relations[field] = &belongsTo[T, R]{parent: b, target: target, field: f, fk: i}
+	return nil
+}
+
+// HasMany declares that field (of type []R, tagged px:"-") of b's documents holds the target documents
+// whose column fk references b's primary key
+func HasMany[T, R any](b *BaseModel[T], field string, target *BaseModel[R], fk string) error {
+	f, e := b.relationField(field, target.Type, true)
+	if e != nil {
+		return e
+	}
+	if len(b.primaryKeys) != 1 {
+		return errors.New("relation " + field + ": table " + b.TableName + " has a composite primary key")
+	}
+	i := target.columnIndex(fk)
+	if i == -1 {
+		return errors.New("relation " + field + ": unknown column '" + fk + "' for table " + target.TableName)
+	}
+	b.relations[field] = &hasMany[T, R]{parent: b, target: target, field: f, fk: i}
+	return nil
+}
+
+// ManyToMany declares that field (of type []R, tagged px:"-") of b's documents holds the target documents linked by joinTable,
+// whose column joinFK references b's primary key and joinTargetFK references target's primary key
+func ManyToMany[T, R any](b *BaseModel[T], field string, target *BaseModel[R], joinTable, joinFK, joinTargetFK string) error {
+	f, e := b.relationField(field, target.Type, true)
+	if e != nil {
+		return e
+	}
+	if len(b.primaryKeys) != 1 || len(target.primaryKeys) != 1 {
+		return errors.New("relation " + field + ": composite primary keys are not supported")
+	}
+	b.relations[field] = &manyToMany[T, R]{parent: b, target: target, field: f, joinTable: joinTable, joinFK: joinFK, joinTargetFK: joinTargetFK}
+	return nil
+}
+
+// relationField checks that field is a non-column field of type target, *target, or []target if many
+func (b *BaseModel[T]) relationField(field string, target reflect.Type, many bool) (int, error) {
+	f, ok := b.Type.FieldByName(field)
+	if !ok || len(f.Index) != 1 {
+		return -1, errors.New("relation " + field + ": no such field in " + b.Type.String())
+	}
+	for _, i := range b.fields {
+		if i == f.Index[0] {
+			return -1, errors.New("relation " + field + ": field must be tagged px:\"-\"")
+		}
+	}
+	if many {
+		if f.Type.Kind() != reflect.Slice || f.Type.Elem() != target {
+			return -1, errors.New("relation " + field + ": field type must be []" + target.String())
+		}
+	} else if f.Type != target && !(f.Type.Kind() == reflect.Ptr && f.Type.Elem() == target) {
+		return -1, errors.New("relation " + field + ": field type must be " + target.String() + " or *" + target.String())
+	}
+	return f.Index[0], nil
+}
+
+// Preload returns a copy of the model that loads the named relations (see BelongsTo, HasMany, ManyToMany)
+// of the documents it queries, with one query per relation
+func (b *BaseModel[T]) Preload(relations ...string) *BaseModel[T] {
+	c := *b
+	c.preloads = append(append([]string{}, b.preloads...), relations...)
+	return &c
+}
+
+// preload loads the relations of vs requested by Preload
+func (b *BaseModel[T]) preload(vs []T) error {
+	if len(vs) == 0 {
+		return nil
+	}
+	for _, name := range b.preloads {
+		r, ok := b.relations[name]
+		if !ok {
+			return errors.New("unknown relation '" + name + "' for " + b.Type.String())
+		}
+		e := r.load(b.tx, vs)
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// keyType returns the Go type of the single primary key column
+func (b *BaseModel[T]) keyType() reflect.Type {
+	return b.Type.Field(b.fields[b.primaryKeys[0]]).Type
+}
+
+// relationKey converts v (possibly an interface, a pointer or sql.Null* type) to keyType, false if it's null or not convertible.
+// Integers aren't converted to strings, and integer conversions must not overflow or change the sign
+func relationKey(v reflect.Value, keyType reflect.Type) (any, bool) {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, e := valuer.Value()
+		if e != nil || value == nil {
+			return nil, false
+		}
+		v = reflect.ValueOf(value)
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.Type().ConvertibleTo(keyType) || !convertsExactly(v, keyType) {
+		return nil, false
+	}
+	return v.Convert(keyType).Interface(), true
+}
+
+// convertsExactly reports whether converting v to t keeps its value: no integer to string conversion, and
+// no integer or float conversion out of t's range, or of a float with a fraction to an integer
+func convertsExactly(v reflect.Value, t reflect.Type) bool {
+	zero := reflect.Zero(t)
+	switch {
+	case isInt(v.Kind()):
+		x := v.Int()
+		switch {
+		case isInt(t.Kind()):
+			return !zero.OverflowInt(x)
+		case isUint(t.Kind()):
+			return x >= 0 && !zero.OverflowUint(uint64(x))
+		case t.Kind() == reflect.String:
+			return false
+		}
+	case isUint(v.Kind()):
+		x := v.Uint()
+		switch {
+		case isInt(t.Kind()):
+			return x <= math.MaxInt64 && !zero.OverflowInt(int64(x))
+		case isUint(t.Kind()):
+			return !zero.OverflowUint(x)
+		case t.Kind() == reflect.String:
+			return false
+		}
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		x := v.Float()
+		switch {
+		case isInt(t.Kind()):
+			return x == math.Trunc(x) && x >= math.MinInt64 && x < math.MaxInt64 && !zero.OverflowInt(int64(x))
+		case isUint(t.Kind()):
+			return x == math.Trunc(x) && x >= 0 && x < math.MaxUint64 && !zero.OverflowUint(uint64(x))
+		case t.Kind() == reflect.Float32:
+			return !zero.OverflowFloat(x)
+		}
+	}
+	return true
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+// collectKeys returns the distinct keys of vs as a []keyType, for a '= any($1)' parameter
+func collectKeys(vs []reflect.Value, keyType reflect.Type) any {
+	keys := reflect.MakeSlice(reflect.SliceOf(keyType), 0, len(vs))
+	seen := make(map[any]bool)
+	for _, v := range vs {
+		key, ok := relationKey(v, keyType)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = reflect.Append(keys, reflect.ValueOf(key))
+	}
+	return keys.Interface()
+}
+
+func withTx[R any](b *BaseModel[R], tx Executor) *BaseModel[R] {
+	if tx == nil {
+		return b
+	}
+	return b.WithTx(tx)
+}
+
+func (r *belongsTo[T, R]) load(tx Executor, parents []T) error {
+	target := withTx(r.target, tx)
+	keyType := target.keyType()
+
+	fks := []reflect.Value{}
+	for i := range parents {
+		fks = append(fks, r.parent.field(reflect.ValueOf(&parents[i]).Elem(), r.fk))
+	}
+	keys := collectKeys(fks, keyType)
+	if reflect.ValueOf(keys).Len() == 0 {
+		return nil
+	}
+
+	_, query := target.GetSelectSQL()
+	query = query + target.scope(` where `+target.TableName+`.`+target.dbTags[target.primaryKeys[0]]+` = any($1)`)
+	vs, e := target.queryAll(query, []any{keys})
+	if e != nil {
+		return e
+	}
+	byKey := make(map[any]*R)
+	for i := range vs {
+		byKey[target.field(reflect.ValueOf(&vs[i]).Elem(), target.primaryKeys[0]).Interface()] = &vs[i]
+	}
+
+	for i := range parents {
+		key, ok := relationKey(fks[i], keyType)
+		if !ok {
+			continue
+		}
+		v, ok := byKey[key]
+		if !ok {
+			continue
+		}
+		field := reflect.ValueOf(&parents[i]).Elem().Field(r.field)
+		if field.Kind() == reflect.Ptr {
+			field.Set(reflect.ValueOf(v))
+		} else {
+			field.Set(reflect.ValueOf(*v))
+		}
+	}
+	return nil
+}
+
+func (r *hasMany[T, R]) load(tx Executor, parents []T) error {
+	target := withTx(r.target, tx)
+	keyType := r.parent.keyType()
+
+	ids := []reflect.Value{}
+	for i := range parents {
+		ids = append(ids, r.parent.field(reflect.ValueOf(&parents[i]).Elem(), r.parent.primaryKeys[0]))
+	}
+	keys := collectKeys(ids, keyType)
+
+	_, query := target.GetSelectSQL()
+	query = query + target.scope(` where `+target.TableName+`.`+target.dbTags[r.fk]+` = any($1)`)
+	vs, e := target.queryAll(query, []any{keys})
+	if e != nil {
+		return e
+	}
+	byKey := make(map[any][]R)
+	for _, v := range vs {
+		key, ok := relationKey(target.field(reflect.ValueOf(&v).Elem(), r.fk), keyType)
+		if ok {
+			byKey[key] = append(byKey[key], v)
+		}
+	}
+
+	for i := range parents {
+		children := []R{}
+		if key, ok := relationKey(ids[i], keyType); ok && byKey[key] != nil {
+			children = byKey[key]
+		}
+		reflect.ValueOf(&parents[i]).Elem().Field(r.field).Set(reflect.ValueOf(children))
+	}
+	return nil
+}
+
+func (r *manyToMany[T, R]) load(tx Executor, parents []T) error {
+	target := withTx(r.target, tx)
+	keyType := r.parent.keyType()
+
+	ids := []reflect.Value{}
+	for i := range parents {
+		ids = append(ids, r.parent.field(reflect.ValueOf(&parents[i]).Elem(), r.parent.primaryKeys[0]))
+	}
+	keys := collectKeys(ids, keyType)
+
+	_, selection := target.GetSelectFields()
+	query := `select ` + r.joinTable + `.` + r.joinFK + `,` + selection + ` from ` + target.TableName +
+		` join ` + r.joinTable + ` on ` + r.joinTable + `.` + r.joinTargetFK + `=` + target.TableName + `.` + target.dbTags[target.primaryKeys[0]] +
+		target.scope(` where `+r.joinTable+`.`+r.joinFK+` = any($1)`)
+	rows, e := target.Executor().Query(context.Background(), query, keys)
+	if e != nil {
+		return target.toError(e, query)
+	}
+
+	owners := []any{}
+	vs := []*R{}
+	for rows.Next() {
+		owner := reflect.New(keyType)
+		v := new(R)
+		e = rows.Scan(append([]any{owner.Interface()}, target.fieldArgs(v)...)...)
+		if e != nil {
+			break
+		}
+		owners = append(owners, owner.Elem().Interface())
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e == nil {
+		e = rows.Err()
+	}
+	if e != nil {
+		return target.toError(e, query)
+	}
+
+	// hooks run once the rows are closed, so they may query the same tx
+	byKey := make(map[any][]R)
+	for i, v := range vs {
+		if e = target.afterFind(v); e != nil {
+			return e
+		}
+		byKey[owners[i]] = append(byKey[owners[i]], *v)
+	}
+
+	for i := range parents {
+		children := []R{}
+		if key, ok := relationKey(ids[i], keyType); ok && byKey[key] != nil {
+			children = byKey[key]
+		}
+		reflect.ValueOf(&parents[i]).Elem().Field(r.field).Set(reflect.ValueOf(children))
+	}
+	return nil
+}
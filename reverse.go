@@ -0,0 +1,494 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iancoleman/strcase"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stevenzack/tools/strToolkit"
+)
+
+type (
+	reverseColumn struct {
+		name      string
+		dataType  string
+		udtName   string
+		nullable  bool
+		maxLength int  // of varchar(n) and char(n), 0 if none
+		unsigned  bool // has a 'column > -1' check constraint
+		sequence  bool // defaults to nextval of a sequence
+		comment   string
+		remote    Column // as DescTable read it
+	}
+	reverseField struct {
+		name   string
+		goType string
+		tags   []string // limit/length tags
+		index  []string // index tag options
+	}
+)
+
+// ReverseModels reads the tables of a schema (every base table if none is given) and returns the Go source of a file of package
+// pkgName declaring a model struct per table, with the field types and limit, length, index and comment tags that NewBaseModel maps back
+// to the same columns and indexes. What tags can't express is left as comments above the struct
+func ReverseModels(pool *pgxpool.Pool, database, schema, pkgName string, tables ...string) ([]byte, error) {
+	if len(tables) == 0 {
+		var e error
+		tables, e = queryStrings(pool, `select table_name from information_schema.tables where table_catalog=$1 and table_schema=$2 and table_type='BASE TABLE' order by table_name`, database, schema)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	builder := new(strings.Builder)
+	usesTime := false
+	for _, table := range tables {
+		src, e := reverseTable(pool, database, schema, table)
+		if e != nil {
+			return nil, e
+		}
+		usesTime = usesTime || strings.Contains(src, "time.Time")
+		builder.WriteString("\n" + src)
+	}
+
+	header := "package " + pkgName + "\n"
+	if usesTime {
+		header += "\nimport \"time\"\n"
+	}
+	return format.Source([]byte(header + builder.String()))
+}
+
+// describeColumns reads the columns of table, unsigned if they have a 'column > -1' check constraint
+func describeColumns(pool *pgxpool.Pool, database, schema, table string) ([]reverseColumn, error) {
+	columns, e := DescTable(pool, database, schema, table)
+	if e != nil {
+		return nil, e
+	}
+
+	out := []reverseColumn{}
+	for _, c := range columns {
+		out = append(out, toReverseColumn(c))
+	}
+	return out, nil
+}
+
+func toReverseColumn(c Column) reverseColumn {
+	v := reverseColumn{name: c.ColumnName, dataType: c.DataType, udtName: c.UdtName, nullable: c.IsNullable == "YES", remote: c}
+	if c.CharacterMaximumLength != nil {
+		v.maxLength = int(*c.CharacterMaximumLength)
+	}
+	if c.Comment != nil {
+		v.comment = *c.Comment
+	}
+	if c.ColumnDefault != nil {
+		v.sequence = strings.HasPrefix(*c.ColumnDefault, "nextval(")
+	}
+	for _, check := range c.Checks {
+		v.unsigned = v.unsigned || isUnsignedCheck(check, c.ColumnName)
+	}
+	return v
+}
+
+// isUnsignedCheck reports whether check, as pg_get_constraintdef prints it, is 'CHECK ((column > -1))'
+// or 'CHECK ((column > '-1'::integer))'
+func isUnsignedCheck(check, column string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimLeft(strings.TrimPrefix(check, "CHECK "), "("), column+" > ")
+	return ok && (strings.HasPrefix(rest, "-1)") || strings.HasPrefix(rest, "'-1'"))
+}
+
+// queryStrings returns the single text column of query's rows
+func queryStrings(pool *pgxpool.Pool, query string, args ...any) ([]string, error) {
+	rows, e := pool.Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, e
+	}
+
+	out := []string{}
+	for rows.Next() {
+		var v string
+		e = rows.Scan(&v)
+		if e != nil {
+			break
+		}
+		out = append(out, v)
+	}
+
+	//check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, e
+	}
+	return out, nil
+}
+
+func reverseTable(pool *pgxpool.Pool, database, schema, table string) (string, error) {
+	columns, e := describeColumns(pool, database, schema, table)
+	if e != nil {
+		return "", e
+	}
+	if len(columns) == 0 {
+		return "", errors.New("table " + schema + "." + table + " not found")
+	}
+	indexes, e := DescIndexes(pool, schema, table)
+	if e != nil {
+		return "", e
+	}
+	primaryKeys := []string{}
+	for _, index := range indexes {
+		if index.Primary {
+			for _, key := range index.Keys {
+				primaryKeys = append(primaryKeys, key.Column)
+			}
+		}
+	}
+
+	//id first
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].name == "id" && columns[j].name != "id" })
+	if columns[0].name != "id" {
+		return "", errors.New("table " + table + " has no id column, which px models require as their first field")
+	}
+
+	//fields
+	notes := []string{}
+	fields := []reverseField{}
+	byColumn := make(map[string]*reverseField)
+	for i, c := range columns {
+		field, e := reverseFieldOf(c, i == 0)
+		if e != nil {
+			return "", errors.New("table " + table + ": " + e.Error())
+		}
+		notes = append(notes, reverseNotes(c, field)...)
+		if c.comment != "" {
+			if strings.Contains(c.comment, "`") {
+				notes = append(notes, "column "+c.name+"'s comment has a backquote, not expressible in tags")
+			} else {
+				field.tags = append(field.tags, "comment:"+strconv.Quote(c.comment))
+			}
+		}
+		fields = append(fields, field)
+	}
+	for i := range fields {
+		byColumn[columns[i].name] = &fields[i]
+	}
+
+	//indexes, single column ones first so group tags can add 'single'
+	sort.SliceStable(indexes, func(i, j int) bool { return len(indexes[i].Keys) == 1 && len(indexes[j].Keys) > 1 })
+	groups := 0
+	for _, index := range indexes {
+		note := reverseIndex(table, index, byColumn, &groups)
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
+	if len(primaryKeys) > 1 || (len(primaryKeys) == 1 && primaryKeys[0] != "id") {
+		if !strToolkit.SliceContains(primaryKeys, "id") {
+			notes = append(notes, "the primary key ("+strings.Join(primaryKeys, ",")+") doesn't include id, but px composite primary keys do")
+		}
+		keys := []IndexKeySchema{}
+		for _, key := range primaryKeys {
+			if key != "id" {
+				keys = append(keys, IndexKeySchema{Column: key})
+			}
+		}
+		if note := addIndexGroup(keys, "pkey", byColumn); note != "" {
+			notes = append(notes, note+": primary key")
+		}
+	}
+
+	//struct
+	name := strcase.ToCamel(pluralizeClient.Singular(table))
+	builder := new(strings.Builder)
+	for _, note := range notes {
+		builder.WriteString("// px: " + note + "\n")
+	}
+	if ToTableName(name) != table {
+		builder.WriteString("// px: the table name is " + table + ", set BaseModel.TableName\n")
+	}
+	builder.WriteString("type " + name + " struct {\n")
+	for _, f := range fields {
+		tags := f.tags
+		if f.index != nil {
+			tags = append(tags, `index:"`+strings.Join(f.index, ",")+`"`)
+		}
+		builder.WriteString("\t" + f.name + " " + f.goType)
+		if len(tags) > 0 {
+			builder.WriteString(" `" + strings.Join(tags, " ") + "`")
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("}\n")
+
+	//table comment
+	comment, e := DescTableComment(pool, schema, table)
+	if e != nil {
+		return "", e
+	}
+	if comment != nil {
+		builder.WriteString("\nfunc (" + name + ") TableComment() string { return " + strconv.Quote(*comment) + " }\n")
+	}
+	return builder.String(), nil
+}
+
+// reverseFieldOf inverts ToPostgreType for a column
+func reverseFieldOf(c reverseColumn, isId bool) (reverseField, error) {
+	field := reverseField{name: strcase.ToCamel(c.name)}
+	if strcase.ToSnake(field.name) != c.name {
+		return field, errors.New("column " + c.name + " has no field name that maps back to it")
+	}
+
+	goType := ""
+	switch c.dataType {
+	case "smallint", "integer", "bigint":
+		bits := map[string]string{"smallint": "16", "integer": "32", "bigint": "64"}[c.dataType]
+		goType = "int" + bits
+		if (isId && c.sequence) || (!isId && c.unsigned) {
+			goType = "uint" + bits
+		}
+	case "double precision":
+		goType = "float64"
+	case "text":
+		goType = "string"
+	case "character varying", "character":
+		if c.maxLength == 0 {
+			return field, errors.New("column " + c.name + ": " + c.dataType + " without a length is not supported")
+		}
+		goType = "string"
+		if c.dataType == "character varying" {
+			field.tags = append(field.tags, `limit:"`+strconv.Itoa(c.maxLength)+`"`)
+		} else {
+			field.tags = append(field.tags, `length:"`+strconv.Itoa(c.maxLength)+`"`)
+		}
+	case "boolean":
+		goType = "bool"
+	case "timestamp with time zone":
+		goType = "time.Time"
+	case "bytea", "ARRAY", "jsonb":
+		if !c.nullable {
+			return field, errors.New("column " + c.name + ": not null " + c.dataType + " is not supported")
+		}
+		switch c.dataType + c.udtName {
+		case "byteabytea":
+			field.goType = "[]byte"
+		case "ARRAY_int4":
+			field.goType = "[]int32"
+		case "ARRAY_int8":
+			field.goType = "[]int64"
+		case "ARRAY_text":
+			field.goType = "[]string"
+		case "jsonbjsonb":
+			field.goType = "map[string]any"
+		default:
+			return field, errors.New("column " + c.name + ": unsupported array type " + c.udtName)
+		}
+		return field, nil
+	default:
+		return field, errors.New("column " + c.name + ": unsupported type " + c.dataType)
+	}
+
+	if isId {
+		if c.nullable || (goType != "string" && !strings.Contains(goType, "int")) {
+			return field, errors.New("column id must be a not null integer or text column")
+		}
+	}
+	if c.nullable {
+		goType = "*" + goType
+	}
+	field.goType = goType
+	return field, nil
+}
+
+// reverseTypes are the reflect types of the Go types reverseFieldOf writes
+var reverseTypes = map[string]reflect.Type{
+	"int16":          reflect.TypeOf(int16(0)),
+	"int32":          reflect.TypeOf(int32(0)),
+	"int64":          reflect.TypeOf(int64(0)),
+	"uint16":         reflect.TypeOf(uint16(0)),
+	"uint32":         reflect.TypeOf(uint32(0)),
+	"uint64":         reflect.TypeOf(uint64(0)),
+	"float64":        reflect.TypeOf(float64(0)),
+	"string":         reflect.TypeOf(""),
+	"bool":           reflect.TypeOf(false),
+	"time.Time":      reflect.TypeOf(time.Time{}),
+	"[]byte":         reflect.TypeOf([]byte(nil)),
+	"[]int32":        reflect.TypeOf([]int32(nil)),
+	"[]int64":        reflect.TypeOf([]int64(nil)),
+	"[]string":       reflect.TypeOf([]string(nil)),
+	"map[string]any": reflect.TypeOf(map[string]any(nil)),
+}
+
+// reverseNotes returns what the field's column, as NewBaseModel declares it, would still conflict with in the remote column,
+// and the check constraints tags can't express
+func reverseNotes(c reverseColumn, field reverseField) []string {
+	out := []string{}
+	if c.name == "id" && !c.sequence && field.goType != "string" {
+		out = append(out, "column id has no sequence default, so Id is signed and inserted as set")
+	}
+	for _, check := range c.remote.Checks {
+		if !isUnsignedCheck(check, c.name) {
+			out = append(out, "column "+c.name+"'s check constraint "+check+" is not expressible in tags")
+		}
+	}
+
+	t, ok := reverseTypes[strings.TrimPrefix(field.goType, "*")]
+	if !ok {
+		return out
+	}
+	if strings.HasPrefix(field.goType, "*") {
+		t = reflect.PointerTo(t)
+	}
+	limit, length := 0, 0
+	if c.dataType == "character varying" {
+		limit = c.maxLength
+	} else {
+		length = c.maxLength
+	}
+	pgType, e := ToPostgreType(t, c.name, length, limit)
+	if e != nil {
+		return append(out, "column "+c.name+": "+e.Error())
+	}
+	return append(out, columnConflicts(c.name, pgType, c.remote)...)
+}
+
+// reverseIndex adds the index tag options of a remote index, or returns a note if tags can't express it
+func reverseIndex(table string, remote IndexSchema, byColumn map[string]*reverseField, groups *int) string {
+	if remote.Primary {
+		return ""
+	}
+	switch remote.Method {
+	case "btree", "hash", "gin", "gist", "brin":
+	default:
+		return "index not expressible in tags: " + remote.IndexDef
+	}
+
+	//single
+	if len(remote.Keys) == 1 {
+		r := remote.Keys[0]
+		key, lower := r.Column, false
+		if expr := normalizeExpression(r.Expression); strings.HasPrefix(expr, "lower(") && strings.HasSuffix(expr, ")") {
+			key, lower = expr[len("lower("):len(expr)-1], true
+		}
+		f := byColumn[key]
+		if f == nil {
+			return "index not expressible in tags: " + remote.IndexDef
+		}
+		if f.index != nil {
+			return "column " + key + " has more than one index, not expressible in tags: " + remote.IndexDef
+		}
+		f.index = []string{}
+		if r.Desc {
+			f.index = append(f.index, "single=desc")
+		}
+		if remote.Unique {
+			f.index = append(f.index, "unique")
+		}
+		if lower {
+			f.index = append(f.index, "lower=true")
+		}
+		if r.OpClass != "" {
+			f.index = append(f.index, "ops="+r.OpClass)
+		}
+		f.index = append(f.index, reverseIndexOptions(table, remote, indexModel{keys: []indexKey{{key: key, lower: lower}}})...)
+		return ""
+	}
+
+	//group
+	keys := []string{}
+	var options *reverseField
+	for _, r := range remote.Keys {
+		if r.Column == "" {
+			return "index not expressible in tags: " + remote.IndexDef
+		}
+		if f := byColumn[r.Column]; f != nil && f.index == nil && options == nil {
+			options = f
+		}
+		keys = append(keys, r.Column)
+	}
+	if !sort.StringsAreSorted(keys) {
+		return "index " + remote.IndexName + " has its keys out of name order, not expressible in tags: " + remote.IndexDef
+	}
+	imodel := indexModel{}
+	for _, key := range keys {
+		imodel.keys = append(imodel.keys, indexKey{key: key})
+	}
+	groupOptions := reverseIndexOptions(table, remote, imodel)
+	if len(groupOptions) > 0 && options == nil {
+		// index level options of a column with its own index apply to that index
+		return "every column of index " + remote.IndexName + " has its own index, not expressible in tags: " + remote.IndexDef
+	}
+	*groups++
+	group := "g" + strconv.Itoa(*groups)
+	if remote.Unique {
+		group = "unique" + strconv.Itoa(*groups)
+	}
+	if note := addIndexGroup(remote.Keys, group, byColumn); note != "" {
+		return note + ": " + remote.IndexDef
+	}
+	if len(groupOptions) > 0 {
+		options.index = append(options.index, groupOptions...)
+	}
+	return ""
+}
+
+// reverseIndexOptions returns the method, where, include and name options of a remote index, imodel holding its keys
+func reverseIndexOptions(table string, remote IndexSchema, imodel indexModel) []string {
+	out := []string{}
+	if remote.Method != "btree" {
+		out = append(out, "method="+remote.Method)
+	}
+	if remote.Predicate != nil {
+		out = append(out, "where="+escapeTagValue(*remote.Predicate))
+	}
+	if len(remote.Include) > 0 {
+		out = append(out, "include="+strings.Join(remote.Include, " "))
+	}
+	if imodel.ToIndexName(table) != remote.IndexName {
+		out = append(out, "name="+remote.IndexName)
+	}
+	return out
+}
+
+// escapeTagValue percent-encodes the characters an index tag option value can't hold
+func escapeTagValue(s string) string {
+	return strings.NewReplacer("%", "%25", ",", "%2C", "&", "%26", "+", "%2B", ";", "%3B", `"`, "%22").Replace(s)
+}
+
+// addIndexGroup tags the columns of keys with 'group=group', or returns a note if a column's tag can't take it
+func addIndexGroup(keys []IndexKeySchema, group string, byColumn map[string]*reverseField) string {
+	for _, key := range keys {
+		f := byColumn[key.Column]
+		if f == nil {
+			return "column " + key.Column + " not found, not expressible in tags"
+		}
+		for _, option := range f.index {
+			if strings.HasPrefix(option, "group=") || option == "lower=true" || strings.HasPrefix(option, "ops=") {
+				return "column " + key.Column + " has another index group, a lower index or an operator class, not expressible in tags"
+			}
+		}
+		if key.OpClass != "" && f.index != nil {
+			// ops applies to both the column's own index and the group
+			return "column " + key.Column + " has its own index and an operator class, not expressible in tags"
+		}
+	}
+	for _, key := range keys {
+		f := byColumn[key.Column]
+		if f.index != nil && (len(f.index) == 0 || !strings.HasPrefix(f.index[0], "single")) {
+			// the column's own index, kept as 'single' alongside the group
+			f.index = append([]string{"single"}, f.index...)
+		}
+		f.index = append(f.index, "group="+group)
+		if key.Desc {
+			f.index = append(f.index, "desc=true")
+		}
+		if key.OpClass != "" {
+			f.index = append(f.index, "ops="+key.OpClass)
+		}
+	}
+	return ""
+}
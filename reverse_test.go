@@ -0,0 +1,62 @@
+package px
+
+import "testing"
+
+func TestIsUnsignedCheck(t *testing.T) {
+	tests := []struct {
+		check, column string
+		want          bool
+	}{
+		{"CHECK ((age > '-1'::integer))", "age", true},
+		{"CHECK ((age > -1))", "age", true},
+		{"CHECK ((age > '-10'::integer))", "age", false},
+		{"CHECK ((age > 0))", "age", false},
+		{"CHECK ((page > '-1'::integer))", "age", false},
+		{"CHECK ((age > '-1'::integer))", "ag", false},
+	}
+	for _, tt := range tests {
+		if got := isUnsignedCheck(tt.check, tt.column); got != tt.want {
+			t.Errorf("isUnsignedCheck(%q, %q) = %v, want %v", tt.check, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestReverseRoundTrip(t *testing.T) {
+	str := func(s string) *string { return &s }
+	length := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name   string
+		isId   bool
+		remote Column
+		goType string
+		notes  int
+	}{
+		{"serial id", true, Column{ColumnName: "id", DataType: "integer", UdtName: "int4", IsNullable: "NO", ColumnDefault: str("nextval('users_id_seq'::regclass)")}, "uint32", 0},
+		{"bigint id without sequence", true, Column{ColumnName: "id", DataType: "bigint", UdtName: "int8", IsNullable: "NO"}, "int64", 1},
+		{"unsigned id without sequence", true, Column{ColumnName: "id", DataType: "bigint", UdtName: "int8", IsNullable: "NO", Checks: []string{"CHECK ((id > '-1'::integer))"}}, "int64", 1},
+		{"int without default", false, Column{ColumnName: "age", DataType: "integer", UdtName: "int4", IsNullable: "NO"}, "int32", 0},
+		{"bool without default", false, Column{ColumnName: "active", DataType: "boolean", UdtName: "bool", IsNullable: "NO"}, "bool", 0},
+		{"timestamp without default", false, Column{ColumnName: "create_time", DataType: "timestamp with time zone", UdtName: "timestamptz", IsNullable: "NO"}, "time.Time", 0},
+		{"timestamp now", false, Column{ColumnName: "create_time", DataType: "timestamp with time zone", UdtName: "timestamptz", IsNullable: "NO", ColumnDefault: str("now()")}, "time.Time", 0},
+		{"unsigned", false, Column{ColumnName: "age", DataType: "integer", UdtName: "int4", IsNullable: "NO", ColumnDefault: str("0"), Checks: []string{"CHECK ((age > '-1'::integer))"}}, "uint32", 0},
+		{"other check", false, Column{ColumnName: "age", DataType: "integer", UdtName: "int4", IsNullable: "NO", Checks: []string{"CHECK ((age < 200))"}}, "int32", 1},
+		{"varchar", false, Column{ColumnName: "name", DataType: "character varying", UdtName: "varchar", CharacterMaximumLength: length(36), IsNullable: "NO", ColumnDefault: str("''::character varying")}, "string", 0},
+		{"nullable unsigned", false, Column{ColumnName: "score", DataType: "bigint", UdtName: "int8", IsNullable: "YES", Checks: []string{"CHECK (((score > '-1'::integer) OR (score = NULL::bigint)))"}}, "*uint64", 0},
+		{"array", false, Column{ColumnName: "tags", DataType: "ARRAY", UdtName: "_text", IsNullable: "YES"}, "[]string", 0},
+		{"other default", false, Column{ColumnName: "age", DataType: "integer", UdtName: "int4", IsNullable: "NO", ColumnDefault: str("5")}, "int32", 1},
+	}
+	for _, tt := range tests {
+		c := toReverseColumn(tt.remote)
+		field, e := reverseFieldOf(c, tt.isId)
+		if e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+		if field.goType != tt.goType {
+			t.Errorf("%s: goType %s, want %s", tt.name, field.goType, tt.goType)
+		}
+		if notes := reverseNotes(c, field); len(notes) != tt.notes {
+			t.Errorf("%s: notes %q, want %d", tt.name, notes, tt.notes)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package px
+
+import (
+	"context"
+	"reflect"
+)
+
+// field returns the struct field of column i in value
+func (b *BaseModel[T]) field(value reflect.Value, i int) reflect.Value {
+	return value.Field(b.fields[i])
+}
+
+// fieldArgs returns pointers to v's column fields, in dbTags order
+func (b *BaseModel[T]) fieldArgs(v *T) []any {
+	p := b.cached()
+	if p.generated {
+		return any(v).(Generated).PxColumnPointers()
+	}
+	value := reflect.ValueOf(v).Elem()
+	out := make([]any, 0, len(b.dbTags))
+	for i := range b.dbTags {
+		out = append(out, b.field(value, i).Addr().Interface())
+	}
+	return out
+}
+
+// fieldArgsOf returns pointers to v's fields, all columns if fields is nil
+func (b *BaseModel[T]) fieldArgsOf(v *T, fields []int) []any {
+	if fields == nil {
+		return b.fieldArgs(v)
+	}
+	p := b.cached()
+	out := make([]any, 0, len(fields))
+	if p.generated {
+		pointers := any(v).(Generated).PxColumnPointers()
+		for _, i := range fields {
+			out = append(out, pointers[i])
+		}
+		return out
+	}
+	value := reflect.ValueOf(v).Elem()
+	for _, i := range fields {
+		out = append(out, b.field(value, i).Addr().Interface())
+	}
+	return out
+}
+
+// queryOne scans the single row selecting every column, runs AfterFind and loads the Preload relations
+func (b *BaseModel[T]) queryOne(query string, args []any) (*T, error) {
+	return b.queryOneFields(query, args, nil)
+}
+
+// queryOneFields is queryOne for a query selecting the fields columns, see fieldArgsOf
+func (b *BaseModel[T]) queryOneFields(query string, args []any, fields []int) (*T, error) {
+	v := new(T)
+	e := b.Executor().QueryRow(context.Background(), query, args...).Scan(b.fieldArgsOf(v, fields)...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+	e = b.afterFind(v)
+	if e != nil {
+		return nil, e
+	}
+	if len(b.preloads) > 0 {
+		vs := []T{*v}
+		e = b.preload(vs)
+		if e != nil {
+			return nil, e
+		}
+		*v = vs[0]
+	}
+	return v, nil
+}
+
+// queryAll scans rows selecting every column, runs AfterFind on each and loads the Preload relations
+func (b *BaseModel[T]) queryAll(query string, args []any) ([]T, error) {
+	return b.queryAllFields(query, args, nil)
+}
+
+// queryAllFields is queryAll for a query selecting the fields columns, see fieldArgsOf
+func (b *BaseModel[T]) queryAllFields(query string, args []any, fields []int) ([]T, error) {
+	rows, e := b.Executor().Query(context.Background(), query, args...)
+	if e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	vs := make([]T, 0, 2)
+	for rows.Next() {
+		var v T
+		e = rows.Scan(b.fieldArgsOf(&v, fields)...)
+		if e != nil {
+			break
+		}
+		vs = append(vs, v)
+	}
+
+	// check err
+	rows.Close()
+	if e = rows.Err(); e != nil {
+		return nil, b.toError(e, query)
+	}
+
+	for i := range vs {
+		e = b.afterFind(&vs[i])
+		if e != nil {
+			return nil, e
+		}
+	}
+	e = b.preload(vs)
+	if e != nil {
+		return nil, e
+	}
+	return vs, nil
+}
@@ -0,0 +1,342 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/stevenzack/tools/strToolkit"
+)
+
+const (
+	CreateTable  = "create table"
+	AddColumn    = "add column"
+	DropColumn   = "drop column"
+	RenameColumn = "rename column"
+	CreateIndex  = "create index"
+	DropIndex    = "drop index"
+	RenameIndex  = "rename index"
+	Comment      = "comment"
+)
+
+// SchemaChange is a statement that brings a remote table closer to its model
+type SchemaChange struct {
+	Kind string // CreateTable, AddColumn, DropColumn, RenameColumn, CreateIndex, DropIndex, RenameIndex or Comment
+	Name string // table, column or index name, the table or column commented on for Comment
+	From string // former name of a renamed column or index
+	SQL  string
+}
+
+// SchemaPlan lists the changes syncing a model's table, the differences no change can resolve,
+// and hints like columns that look renamed
+type SchemaPlan struct {
+	Schema      string
+	TableName   string
+	Changes     []SchemaChange
+	Conflicts   []string
+	Suggestions []string
+}
+
+// SchemaSyncer is implemented by every *BaseModel, see Register
+type SchemaSyncer interface {
+	PlanSchema() (*SchemaPlan, error)
+	ApplySchema(plan *SchemaPlan) error
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case CreateTable:
+		return "+ table " + c.Name
+	case AddColumn:
+		return "+ column " + c.Name
+	case DropColumn:
+		return "- column " + c.Name
+	case RenameColumn:
+		return "~ column " + c.From + " -> " + c.Name
+	case CreateIndex:
+		return "+ index " + c.Name
+	case DropIndex:
+		return "- index " + c.Name
+	case RenameIndex:
+		return "~ index " + c.From + " -> " + c.Name
+	case Comment:
+		return "~ comment " + c.Name
+	}
+	return c.Kind + " " + c.Name
+}
+
+// Created reports whether the plan creates the table
+func (p *SchemaPlan) Created() bool {
+	return len(p.Changes) > 0 && p.Changes[0].Kind == CreateTable
+}
+
+// Empty reports whether the remote table matches the model
+func (p *SchemaPlan) Empty() bool {
+	return len(p.Changes) == 0 && len(p.Conflicts) == 0
+}
+
+// PlanSchema compares the model with its remote table and indexes, without changing anything
+func (b *BaseModel[T]) PlanSchema() (*SchemaPlan, error) {
+	plan := &SchemaPlan{Schema: b.Schema, TableName: b.TableName}
+
+	//desc
+	remoteColumnList, e := DescTable(b.Pool, b.Database, b.Schema, b.TableName)
+	if e != nil {
+		return nil, e
+	}
+
+	//create table
+	if len(remoteColumnList) == 0 {
+		plan.Changes = append(plan.Changes, SchemaChange{Kind: CreateTable, Name: b.TableName, SQL: b.GetCreateTableSQL(b.primaryKeyModel)})
+		for _, local := range b.indexes {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: CreateIndex, Name: local.ToIndexName(b.TableName), SQL: b.createIndexSQL(local, false)})
+		}
+		plan.Changes = append(plan.Changes, b.commentChanges(nil, nil)...)
+		return plan, nil
+	}
+
+	// columns check
+	remoteColumns := make(map[string]Column)
+	for _, c := range remoteColumnList {
+		remoteColumns[c.ColumnName] = c
+	}
+
+	// local columns to be created, or renamed from a former name
+	localColumns := make(map[string]string)
+	renames := make(map[string]string) // former name -> column
+	for _, db := range b.dbTags {
+		localColumns[db] = ""
+	}
+	added := []int{}
+	matched := make(map[string]Column) // local column -> its remote column
+	for i, db := range b.dbTags {
+		localColumns[db] = b.pgTypes[i]
+
+		remote, ok := remoteColumns[db]
+		if !ok {
+			for _, from := range b.renamedFrom[db] {
+				former, exists := remoteColumns[from]
+				if _, local := localColumns[from]; !exists || local || renames[from] != "" {
+					continue
+				}
+				renames[from] = db
+				remote, ok = former, true
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: RenameColumn, Name: db, From: from, SQL: `alter table ` + b.Schema + `.` + b.TableName + ` rename column ` + from + ` to ` + db})
+				break
+			}
+		}
+		if !ok {
+			added = append(added, i)
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: AddColumn, Name: db, SQL: `alter table ` + b.Schema + `.` + b.TableName + ` add column ` + db + ` ` + b.pgTypes[i]})
+			continue
+		}
+
+		matched[db] = remote
+		plan.Conflicts = append(plan.Conflicts, columnConflicts(db, b.pgTypes[i], remote)...)
+	}
+
+	//remote columns to be dropped
+	for _, remote := range remoteColumnList {
+		if _, ok := localColumns[remote.ColumnName]; ok || renames[remote.ColumnName] != "" {
+			continue
+		}
+		plan.Changes = append(plan.Changes, SchemaChange{Kind: DropColumn, Name: remote.ColumnName, SQL: `alter table ` + b.Schema + `.` + b.TableName + ` drop column ` + remote.ColumnName})
+
+		// an added column of the same type is likely the same one renamed
+		candidates := []string{}
+		for _, i := range added {
+			if len(columnConflicts(b.dbTags[i], b.pgTypes[i], remote)) == 0 {
+				candidates = append(candidates, b.dbTags[i])
+			}
+		}
+		if len(candidates) == 1 {
+			plan.Suggestions = append(plan.Suggestions, "column "+remote.ColumnName+" may have been renamed to "+candidates[0]+`, tag its field renamed_from:"`+remote.ColumnName+`" to keep the data`)
+		}
+	}
+
+	// comments
+	remoteComment, e := DescTableComment(b.Pool, b.Schema, b.TableName)
+	if e != nil {
+		return nil, e
+	}
+	plan.Changes = append(plan.Changes, b.commentChanges(remoteComment, matched)...)
+
+	// index check
+	remoteIndexList, e := b.GetIndexes()
+	if e != nil {
+		return nil, e
+	}
+	remoteIndexes := make(map[string]IndexSchema)
+	for i, remote := range remoteIndexList {
+		// as the renames leave them
+		remoteIndexList[i] = remote.renameColumns(renames)
+		remoteIndexes[remote.IndexName] = remoteIndexList[i]
+	}
+
+	// index builds in progress, whose indexes are invalid until they finish
+	building := make(map[string]bool)
+	for _, remote := range remoteIndexList {
+		if !remote.Valid {
+			progress, e := DescIndexProgress(b.Pool, b.Schema, b.TableName)
+			if e != nil {
+				return nil, e
+			}
+			for _, p := range progress {
+				building[p.IndexName] = true
+			}
+			break
+		}
+	}
+
+	// indexes to be created, or renamed after their renamed columns
+	localIndexes := make(map[string]indexModel)
+	renamedIndexes := make(map[string]bool)
+	rebuilds := make(map[string]bool) // temporary names of concurrent rebuilds -> whether a change drops it already
+	for _, local := range b.indexes {
+		name := local.ToIndexName(b.TableName)
+		localIndexes[name] = local
+		rebuilds[rebuildName(name)] = false
+	}
+	for _, local := range b.indexes {
+		name := local.ToIndexName(b.TableName)
+		remote, ok := remoteIndexes[name]
+		if from := local.formerName(b.TableName, renames); !ok && from != name {
+			if remote, ok = remoteIndexes[from]; ok {
+				renamedIndexes[from] = true
+				plan.Changes = append(plan.Changes, SchemaChange{Kind: RenameIndex, Name: name, From: from, SQL: `alter index ` + remote.SchemaName + `.` + from + ` rename to ` + name})
+				remote.IndexName = name
+			}
+		}
+		if !ok {
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: CreateIndex, Name: name, SQL: b.createIndexSQL(local, ConcurrentIndexes)})
+			continue
+		}
+
+		if building[name] {
+			plan.Suggestions = append(plan.Suggestions, "index "+name+" is being built by another session, sync again once it's done")
+			continue
+		}
+
+		//definition check, recreate if it differs or a failed build left it invalid
+		if remote.Valid && local.matches(remote) {
+			continue
+		}
+		if !ConcurrentIndexes || !remote.Valid {
+			plan.Changes = append(plan.Changes,
+				SchemaChange{Kind: DropIndex, Name: name, SQL: dropIndexSQL(remote)},
+				SchemaChange{Kind: CreateIndex, Name: name, SQL: b.createIndexSQL(local, ConcurrentIndexes)})
+			continue
+		}
+
+		// build the new definition beside the old index, which keeps serving queries and enforcing uniqueness until the swap
+		tmp := rebuildName(name)
+		if building[tmp] {
+			plan.Suggestions = append(plan.Suggestions, "index "+tmp+" is being built by another session, sync again once it's done")
+			continue
+		}
+		if leftover, ok := remoteIndexes[tmp]; ok {
+			rebuilds[tmp] = true
+			plan.Changes = append(plan.Changes, SchemaChange{Kind: DropIndex, Name: tmp, SQL: dropIndexSQL(leftover)})
+		}
+		next := local
+		next.name = tmp
+		plan.Changes = append(plan.Changes,
+			SchemaChange{Kind: CreateIndex, Name: tmp, SQL: b.createIndexSQL(next, true)},
+			SchemaChange{Kind: DropIndex, Name: name, SQL: dropIndexSQL(remote)},
+			SchemaChange{Kind: RenameIndex, Name: name, From: tmp, SQL: `alter index ` + remote.SchemaName + `.` + tmp + ` rename to ` + name})
+	}
+
+	//indexes to be dropped, only those named after a column of the model, and leftovers of failed rebuilds
+	for _, remote := range remoteIndexList {
+		if remote.Primary || building[remote.IndexName] {
+			continue
+		}
+		if _, ok := localIndexes[remote.IndexName]; ok || renamedIndexes[remote.IndexName] {
+			continue
+		}
+		dropped, leftover := rebuilds[remote.IndexName]
+		if dropped || (!leftover && !strToolkit.SliceContains(b.dbTags, convertIndexToFieldName(b.TableName, remote.IndexName))) {
+			continue
+		}
+		plan.Changes = append(plan.Changes, SchemaChange{Kind: DropIndex, Name: remote.IndexName, SQL: dropIndexSQL(remote)})
+	}
+	return plan, nil
+}
+
+// ApplySchema runs the changes of plan. It fails without changing anything if the plan has conflicts,
+// or drops columns while AutoDropRemoteColumn is false
+func (b *BaseModel[T]) ApplySchema(plan *SchemaPlan) error {
+	if len(plan.Conflicts) > 0 {
+		return errors.New(plan.Conflicts[0])
+	}
+	if !AutoDropRemoteColumn {
+		for _, change := range plan.Changes {
+			if change.Kind == DropColumn {
+				return errors.New("Remote column '" + change.Name + "' to be dropped")
+			}
+		}
+	}
+
+	created := plan.Created()
+	for _, change := range plan.Changes {
+		log.Println(change.SQL)
+		stop := func() {}
+		if change.Kind == CreateIndex && !created {
+			stop = b.logIndexProgress()
+		}
+		// a single statement, outside any transaction as create index concurrently requires
+		_, e := b.Pool.Exec(context.Background(), change.SQL)
+		stop()
+		if e != nil {
+			return fmt.Errorf("%w:%s", e, change.SQL)
+		}
+	}
+	return nil
+}
+
+// logIndexProgress logs the progress of the table's index builds every IndexProgressInterval until stop is called
+func (b *BaseModel[T]) logIndexProgress() (stop func()) {
+	if IndexProgressInterval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(IndexProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			vs, e := DescIndexProgress(b.Pool, b.Schema, b.TableName)
+			if e != nil {
+				log.Println(e)
+				return
+			}
+			for _, v := range vs {
+				log.Println(v.String())
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// rebuildName returns the temporary name a concurrent rebuild of index name builds under, within the 63 bytes of a name
+func rebuildName(name string) string {
+	const suffix = "_pxnew"
+	if len(name) > 63-len(suffix) {
+		name = name[:63-len(suffix)]
+	}
+	return name + suffix
+}
+
+// dropIndexSQL drops a remote index, concurrently if ConcurrentIndexes is set
+func dropIndexSQL(remote IndexSchema) string {
+	if ConcurrentIndexes {
+		return `drop index concurrently ` + remote.SchemaName + `.` + remote.IndexName
+	}
+	return `drop index ` + remote.SchemaName + `.` + remote.IndexName
+}
@@ -0,0 +1,16 @@
+package px
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebuildName(t *testing.T) {
+	if got := rebuildName("users_email_idx"); got != "users_email_idx_pxnew" {
+		t.Errorf("rebuildName = %q", got)
+	}
+	long := strings.Repeat("x", 63)
+	if got := rebuildName(long); len(got) != 63 || !strings.HasSuffix(got, "_pxnew") {
+		t.Errorf("rebuildName of a 63 byte name = %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+package px
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var trailingClauses = []string{" group by ", " order by ", " limit ", " offset ", " for update", " for share"}
+
+// Unscoped returns a copy of the model that neither excludes soft deleted rows nor soft deletes, Delete removes rows physically
+func (b *BaseModel[T]) Unscoped() *BaseModel[T] {
+	c := *b
+	c.unscoped = true
+	return &c
+}
+
+// HardDelete physically deletes the document by id, even if the model has a px:"softdelete" field
+func (b *BaseModel[T]) HardDelete(id any) (int64, error) {
+	return b.Unscoped().Delete(id)
+}
+
+// HardDeleteWhere physically deletes documents that match 'where' condition, even if the model has a px:"softdelete" field
+func (b *BaseModel[T]) HardDeleteWhere(where string, args ...any) (int64, error) {
+	return b.Unscoped().DeleteWhere(where, args...)
+}
+
+// Restore clears the soft delete mark of the document by its primary key, given like DeleteByKey's
+func (b *BaseModel[T]) Restore(keys ...any) (int64, error) {
+	if b.softDelete == -1 {
+		return 0, errors.New("table " + b.TableName + " has no softdelete field")
+	}
+	args, e := b.keyArgs(keys)
+	if e != nil {
+		return 0, e
+	}
+	query := `update ` + b.TableName + ` set ` + b.dbTags[b.softDelete] + `=null` + b.keyWhere(1) + ` and ` + b.dbTags[b.softDelete] + ` is not null`
+	result, e := b.Executor().Exec(context.Background(), query, args...)
+	if e != nil {
+		return 0, b.toError(e, query)
+	}
+	return result.RowsAffected(), nil
+}
+
+// deleteSQL returns 'delete from table', or 'update table set deleted_at=now()' for soft delete models
+func (b *BaseModel[T]) deleteSQL() string {
+	if b.softDelete == -1 || b.unscoped {
+		return `delete from ` + b.TableName
+	}
+	return `update ` + b.TableName + ` set ` + b.dbTags[b.softDelete] + `=now()`
+}
+
+// scope adds the 'deleted_at is null' condition to where (as returned by toWhere), keeping any trailing order/limit clause
+func (b *BaseModel[T]) scope(where string) string {
+	if b.softDelete == -1 || b.unscoped {
+		return where
+	}
+	cond := b.TableName + "." + b.dbTags[b.softDelete] + " is null"
+
+	trimmed := strings.TrimSpace(where)
+	if !strings.HasPrefix(strings.ToLower(trimmed), "where") {
+		// empty, or starts with order/limit
+		return " where " + cond + where
+	}
+
+	body := strings.TrimSpace(trimmed[len("where"):])
+	tail := ""
+	if i := trailingClauseIndex(body); i > -1 {
+		body, tail = body[:i], body[i:]
+	}
+	return " where (" + body + ") and " + cond + tail
+}
+
+// trailingClauseIndex returns the index of the first top-level group/order/limit/offset/for clause in a where body, or -1
+func trailingClauseIndex(body string) int {
+	lower := strings.ToLower(body)
+	depth := 0
+	quoted := false
+	for i := 0; i < len(lower); i++ {
+		switch lower[i] {
+		case '\'':
+			quoted = !quoted
+			continue
+		case '(':
+			if !quoted {
+				depth++
+			}
+			continue
+		case ')':
+			if !quoted {
+				depth--
+			}
+			continue
+		}
+		if quoted || depth > 0 {
+			continue
+		}
+		for _, clause := range trailingClauses {
+			if strings.HasPrefix(lower[i:], clause) {
+				return i
+			}
+		}
+	}
+	return -1
+}
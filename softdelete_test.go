@@ -0,0 +1,51 @@
+package px
+
+import "testing"
+
+func TestTrailingClauseIndex(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{"a=$1", -1},
+		{"a=$1 order by id", 4},
+		{"a=$1 ORDER BY id limit 10", 4},
+		{"a=$1 limit 10 offset 20", 4},
+		{"a in (select b from c order by b limit 1)", -1},
+		{"note=' order by ' and a=1", -1},
+		{"a=1 for update", 3},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := trailingClauseIndex(tt.body); got != tt.want {
+			t.Errorf("trailingClauseIndex(%q) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	b := &BaseModel[struct{}]{TableName: "posts", dbTags: []string{"id", "title", "deleted_at"}, softDelete: 2}
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"", " where posts.deleted_at is null"},
+		{" order by id", " where posts.deleted_at is null order by id"},
+		{" where title=$1", " where (title=$1) and posts.deleted_at is null"},
+		{" where a=1 or b=2 order by id limit 5", " where (a=1 or b=2) and posts.deleted_at is null order by id limit 5"},
+		{" WHERE title=$1", " where (title=$1) and posts.deleted_at is null"},
+	}
+	for _, tt := range tests {
+		if got := b.scope(tt.where); got != tt.want {
+			t.Errorf("scope(%q) = %q, want %q", tt.where, got, tt.want)
+		}
+	}
+
+	if got := b.Unscoped().scope(" where title=$1"); got != " where title=$1" {
+		t.Errorf("unscoped scope = %q", got)
+	}
+	plain := &BaseModel[struct{}]{TableName: "posts", dbTags: []string{"id"}, softDelete: -1}
+	if got := plain.scope(" where id=$1"); got != " where id=$1" {
+		t.Errorf("scope without softdelete = %q", got)
+	}
+}
@@ -0,0 +1,55 @@
+package px
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+func isTimeType(t reflect.Type) bool {
+	switch t.String() {
+	case "time.Time", "sql.NullTime", "*time.Time":
+		return true
+	}
+	return false
+}
+
+// isAutoTime reports whether field i is the px:"created" or px:"updated" column
+func (b *BaseModel[T]) isAutoTime(i int) bool {
+	return i == b.created || i == b.updated
+}
+
+// autoTime returns the value for an automatic timestamp argument, nil lets coalesce($n,now()) fall back to the server time
+func autoTime() any {
+	if NowFunc == nil {
+		return nil
+	}
+	return NowFunc()
+}
+
+// autoUpdateSet appends the px:"version" increment and the px:"updated" column to sets, unless sets already assigns them
+func (b *BaseModel[T]) autoUpdateSet(sets string, args []any) (string, []any) {
+	if b.version != -1 && !assigns(sets, b.dbTags[b.version]) {
+		sets = sets + "," + b.dbTags[b.version] + "=" + b.dbTags[b.version] + "+1"
+	}
+	if b.updated == -1 || assigns(sets, b.dbTags[b.updated]) {
+		return sets, args
+	}
+	column := b.dbTags[b.updated]
+
+	if NowFunc == nil {
+		return sets + "," + column + "=now()", args
+	}
+	args = append(args[:len(args):len(args)], NowFunc())
+	return sets + "," + column + "=$" + strconv.Itoa(len(args)), args
+}
+
+// assigns reports whether the 'a=$1,b=$2' sets assign column
+func assigns(sets, column string) bool {
+	for _, assign := range strings.Split(strings.ReplaceAll(strings.ToLower(sets), " ", ""), ",") {
+		if strings.HasPrefix(assign, column+"=") {
+			return true
+		}
+	}
+	return false
+}